@@ -0,0 +1,196 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Criteria is structured AQL search criteria, rendered as the JSON argument to `find`
+type Criteria map[string]interface{}
+
+// ParseCriteria parses raw AQL search criteria, e.g. `{"repo": "artifacts-local"}`
+func ParseCriteria(raw string) (Criteria, error) {
+	c := Criteria{}
+
+	if strings.TrimSpace(raw) == "" {
+		return c, nil
+	}
+
+	d := json.NewDecoder(strings.NewReader(raw))
+	d.UseNumber()
+
+	err := d.Decode(&c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aql criteria: %s", err)
+	}
+
+	if d.More() {
+		return nil, fmt.Errorf("invalid aql criteria: unexpected data after criteria object")
+	}
+
+	return c, nil
+}
+
+// equal returns criteria matching a field to an exact value
+func equal(field string, value interface{}) Criteria {
+	return Criteria{field: value}
+}
+
+// compare returns criteria comparing a field to a value using an AQL operator, e.g. `$match`
+func compare(field, operator string, value interface{}) Criteria {
+	return Criteria{field: Criteria{operator: value}}
+}
+
+// greaterThan returns criteria matching a field greater than a value
+func greaterThan(field string, value interface{}) Criteria {
+	return compare(field, "$gt", value)
+}
+
+// and combines criteria under `$and`, skipping empty criteria & flattening existing `$and` clauses
+func and(criteria ...Criteria) Criteria {
+	clauses := []interface{}{}
+
+	for _, c := range criteria {
+		if len(c) == 0 {
+			continue
+		}
+
+		if nested, ok := c["$and"].([]interface{}); ok && len(c) == 1 {
+			clauses = append(clauses, nested...)
+			continue
+		}
+
+		clauses = append(clauses, c)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return Criteria{}
+	case 1:
+		return toCriteria(clauses[0])
+	}
+
+	return Criteria{"$and": clauses}
+}
+
+// String renders the criteria as JSON for use within an AQL query
+func (c Criteria) String() string {
+	var buf bytes.Buffer
+
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+
+	err := e.Encode(c)
+	if err != nil {
+		return "{}"
+	}
+
+	return strings.TrimSpace(buf.String())
+}
+
+// PropertyCriteria matches the value of an artifact property, a plain string is treated as Equal
+type PropertyCriteria struct {
+	Equal    string `json:"equal,omitempty"`     // Equal matches the exact property value
+	NotEqual string `json:"not_equal,omitempty"` // NotEqual matches any other property value
+	Match    string `json:"match,omitempty"`     // Match matches the property value to a wildcard pattern
+	NotMatch string `json:"not_match,omitempty"` // NotMatch excludes property values matching a wildcard pattern
+}
+
+// UnmarshalJSON custom unmarshaller to support plain string values
+func (p *PropertyCriteria) UnmarshalJSON(data []byte) error {
+	var v string
+	if json.Unmarshal(data, &v) == nil {
+		p.Equal = v
+		return nil
+	}
+
+	type Alias PropertyCriteria
+	return json.Unmarshal(data, (*Alias)(p))
+}
+
+// Empty returns true if no operator is defined
+func (p PropertyCriteria) Empty() bool {
+	return p.Equal == "" && p.NotEqual == "" && p.Match == "" && p.NotMatch == ""
+}
+
+// Criteria returns the `@key` criteria for the property
+func (p PropertyCriteria) Criteria(key string) Criteria {
+	field := "@" + key
+	c := []Criteria{}
+
+	if p.Equal != "" {
+		c = append(c, equal(field, p.Equal))
+	}
+	if p.NotEqual != "" {
+		c = append(c, compare(field, "$ne", p.NotEqual))
+	}
+	if p.Match != "" {
+		c = append(c, compare(field, "$match", p.Match))
+	}
+	if p.NotMatch != "" {
+		c = append(c, compare(field, "$nmatch", p.NotMatch))
+	}
+
+	return and(c...)
+}
+
+// propertiesCriteria returns the `@key` criteria for every property, ordered by key
+func propertiesCriteria(props map[string]PropertyCriteria) Criteria {
+	keys := []string{}
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	c := []Criteria{}
+	for _, k := range keys {
+		c = append(c, props[k].Criteria(k))
+	}
+
+	return and(c...)
+}
+
+func validateProperties(prefix string, props map[string]PropertyCriteria) error {
+	for k, p := range props {
+		if k == "" || p.Empty() {
+			return fmt.Errorf("%s property %q requires a key & value", prefix, k)
+		}
+	}
+
+	return nil
+}
+
+// namesRepo reports whether the criteria require an exact repository, directly or within `$and`
+func namesRepo(c Criteria) bool {
+	if v, ok := c["repo"]; ok {
+		r, ok := v.(string)
+		if !ok {
+			r, ok = toCriteria(v)["$eq"].(string)
+		}
+
+		return ok && r != "" && !strings.ContainsAny(r, "*?")
+	}
+
+	nested, _ := c["$and"].([]interface{})
+	for _, n := range nested {
+		if namesRepo(toCriteria(n)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toCriteria(v interface{}) Criteria {
+	switch c := v.(type) {
+	case Criteria:
+		return c
+	case map[string]interface{}:
+		return Criteria(c)
+	}
+
+	return Criteria{}
+}
@@ -0,0 +1,331 @@
+package resource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Supported archive formats
+const (
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+	formatTarXz = "tar.xz"
+	formatZip   = "zip"
+)
+
+// archiveFormat returns the archive format based on the file name, empty when not a supported archive
+func archiveFormat(name string) string {
+	n := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(n, ".tar.gz"), strings.HasSuffix(n, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(n, ".tar.xz"), strings.HasSuffix(n, ".txz"):
+		return formatTarXz
+	case strings.HasSuffix(n, ".tar"):
+		return formatTar
+	case strings.HasSuffix(n, ".zip"):
+		return formatZip
+	}
+
+	return ""
+}
+
+// unpack extracts a supported archive into dir, returning false when the file is not a supported archive
+func unpack(path, dir string) (bool, error) {
+	format := archiveFormat(path)
+	if format == "" {
+		return false, nil
+	}
+
+	log.Println("unpacking", format, "archive:", path)
+
+	if format == formatZip {
+		return true, extractZip(path, dir)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return true, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+
+	switch format {
+	case formatTarGz:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return true, err
+		}
+		defer gz.Close()
+
+		r = gz
+	case formatTarXz:
+		r, err = xz.NewReader(f)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, extractTar(r, dir)
+}
+
+func extractTar(r io.Reader, dir string) error {
+	t := tar.NewReader(r)
+
+	for {
+		h, err := t.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		p, err := safeJoin(dir, h.Name)
+		if err != nil {
+			return err
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = safeMkdir(dir, p)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeFile(p, t, os.FileMode(h.Mode))
+		case tar.TypeSymlink:
+			err = symlink(dir, p, h.Linkname)
+		default:
+			log.Println("skipping unsupported archive entry:", h.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(path, dir string) error {
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		p, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			err = safeMkdir(dir, p)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		err = writeFile(p, r, f.Mode())
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// safeJoin joins an archive entry name to dir, rejecting entries that would escape dir, including through links
+// already extracted to its parent directories
+func safeJoin(dir, name string) (string, error) {
+	p := filepath.Join(dir, name)
+	if !within(dir, p) {
+		return "", fmt.Errorf("archive entry outside of destination: %s", name)
+	}
+
+	ok, err := resolvedWithin(dir, filepath.Dir(p))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("archive entry outside of destination through a link: %s", name)
+	}
+
+	return p, nil
+}
+
+// safeMkdir creates the directory p, rejecting an existing link at p that escapes dir
+func safeMkdir(dir, p string) error {
+	ok, err := resolvedWithin(dir, p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("archive entry outside of destination through a link: %s", p)
+	}
+
+	return os.MkdirAll(p, os.ModePerm)
+}
+
+// symlink creates a link at p, rejecting targets that would escape dir
+func symlink(dir, p, target string) error {
+	resolved := target
+	if !filepath.IsAbs(target) {
+		resolved = filepath.Join(filepath.Dir(p), target)
+	}
+
+	if !within(dir, resolved) {
+		return fmt.Errorf("archive link outside of destination: %s -> %s", p, target)
+	}
+
+	// the target is resolved without cleaning it first, `..` following a link leaves the directory the link points to
+	resolved = target
+	if !filepath.IsAbs(target) {
+		resolved = filepath.Dir(p) + string(os.PathSeparator) + target
+	}
+
+	ok, err := resolvedWithin(dir, resolved)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("archive link outside of destination through a link: %s -> %s", p, target)
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = removeLink(p)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(target, p)
+}
+
+func within(dir, p string) bool {
+	dir = filepath.Clean(dir)
+	p = filepath.Clean(p)
+
+	return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+}
+
+// resolvedWithin reports whether p is within dir once the links of both are followed
+func resolvedWithin(dir, p string) (bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+
+	d, err := resolvePath(dir, 0)
+	if err != nil {
+		return false, err
+	}
+
+	r, err := resolvePath(p, 0)
+	if err != nil {
+		return false, err
+	}
+
+	return within(d, r), nil
+}
+
+// maxLinks followed resolving a single path
+const maxLinks = 255
+
+// resolvePath follows the links of the absolute path p one element at a time, elements that do not exist are kept as is
+func resolvePath(p string, links int) (string, error) {
+	resolved := string(os.PathSeparator)
+
+	for _, e := range strings.Split(p, string(os.PathSeparator)) {
+		switch e {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, e)
+
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxLinks {
+			return "", fmt.Errorf("too many links resolving %s", p)
+		}
+
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+
+		if !filepath.IsAbs(target) {
+			target = resolved + string(os.PathSeparator) + target
+		}
+
+		resolved, err = resolvePath(target, links)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return resolved, nil
+}
+
+// removeLink removes an existing link at p so it is replaced rather than followed
+func removeLink(p string) error {
+	info, err := os.Lstat(p)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	return os.Remove(p)
+}
+
+func writeFile(p string, r io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = removeLink(p)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+
+	return err
+}
@@ -0,0 +1,137 @@
+package resource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+	"github.com/ulikunitz/xz"
+)
+
+type entry struct {
+	name string
+	body string
+	link string
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []entry) {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		switch {
+		case e.link != "":
+			h = &tar.Header{Name: e.name, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		case strings.HasSuffix(e.name, "/"):
+			h = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+
+		Expect(t, tw.WriteHeader(h)).To(BeNil())
+		_, err := tw.Write([]byte(e.body))
+		Expect(t, err).To(BeNil())
+	}
+	Expect(t, tw.Close()).To(BeNil())
+}
+
+func archive(t *testing.T, format string, entries []entry) []byte {
+	var buf bytes.Buffer
+
+	switch format {
+	case formatTar:
+		writeTar(t, &buf, entries)
+	case formatTarGz:
+		gw := gzip.NewWriter(&buf)
+		writeTar(t, gw, entries)
+		Expect(t, gw.Close()).To(BeNil())
+	case formatTarXz:
+		xw, err := xz.NewWriter(&buf)
+		Expect(t, err).To(BeNil())
+		writeTar(t, xw, entries)
+		Expect(t, xw.Close()).To(BeNil())
+	case formatZip:
+		zw := zip.NewWriter(&buf)
+		for _, e := range entries {
+			w, err := zw.Create(e.name)
+			Expect(t, err).To(BeNil())
+			_, err = w.Write([]byte(e.body))
+			Expect(t, err).To(BeNil())
+		}
+		Expect(t, zw.Close()).To(BeNil())
+	}
+
+	return buf.Bytes()
+}
+
+func TestUnpack(t *testing.T) {
+	valid := []entry{{name: "bin/myapp", body: "binary"}, {name: "README.md", body: "readme"}}
+
+	tests := []struct {
+		description string
+		name        string
+		format      string
+		entries     []entry
+		unpacked    bool
+		expectError bool
+	}{
+		{description: "tar", name: "myapp.tar", format: formatTar, entries: valid, unpacked: true},
+		{description: "tar.gz", name: "myapp.tar.gz", format: formatTarGz, entries: valid, unpacked: true},
+		{description: "tgz", name: "myapp.tgz", format: formatTarGz, entries: valid, unpacked: true},
+		{description: "tar.xz", name: "myapp.tar.xz", format: formatTarXz, entries: valid, unpacked: true},
+		{description: "zip", name: "myapp.zip", format: formatZip, entries: valid, unpacked: true},
+		{description: "not an archive", name: "myapp.bin", format: formatTar, entries: valid, unpacked: false},
+		{description: "tar traversal", name: "evil.tar", format: formatTar, entries: []entry{{name: "../../evil", body: "evil"}}, unpacked: true, expectError: true},
+		{description: "zip traversal", name: "evil.zip", format: formatZip, entries: []entry{{name: "../evil", body: "evil"}}, unpacked: true, expectError: true},
+		{description: "tar link traversal", name: "evil.tgz", format: formatTarGz, entries: []entry{{name: "passwd", link: "/etc/passwd"}}, unpacked: true, expectError: true},
+		{description: "tar link within destination", name: "myapp.tar", format: formatTar, entries: []entry{{name: "bin/myapp", body: "binary"}, {name: "current", link: "bin"}, {name: "current/extra", body: "extra"}}, unpacked: true},
+		{description: "tar chained link traversal", name: "evil.tar", format: formatTar, entries: []entry{{name: "x", link: "."}, {name: "x/y", link: ".."}, {name: "y/evil", body: "evil"}}, unpacked: true, expectError: true},
+		{description: "tar link through later link traversal", name: "evil.tar", format: formatTar, entries: []entry{{name: "c", link: "a/../victim"}, {name: "a", link: "."}, {name: "c/", body: ""}}, unpacked: true, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "artifactory-resource")
+			Expect(t, err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, tc.name)
+			Expect(t, ioutil.WriteFile(path, archive(t, tc.format, tc.entries), 0644)).To(BeNil())
+
+			dest := filepath.Join(dir, "out")
+			ok, err := unpack(path, dest)
+			Expect(t, ok).To(Equal(tc.unpacked))
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+
+				for _, name := range []string{"evil", "victim"} {
+					_, err = os.Lstat(filepath.Join(dir, name))
+					Expect(t, os.IsNotExist(err)).To(BeTrue())
+				}
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			if !tc.unpacked {
+				return
+			}
+
+			for _, e := range tc.entries {
+				if e.link != "" {
+					continue
+				}
+
+				data, err := ioutil.ReadFile(filepath.Join(dest, e.name))
+				Expect(t, err).To(BeNil())
+				Expect(t, string(data)).To(Equal(e.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+)
+
+// BuildQuery provides the build query structure, used to trigger on builds instead of items
+type BuildQuery struct {
+	Name   string `json:"name"`             // Name of the build to find, may contain `*` wildcards
+	Status string `json:"status,omitempty"` // Status of the latest build promotion to match, e.g. `released`, earlier promotions are ignored
+}
+
+// Query returns the `builds.find` query for builds started after since
+func (b *BuildQuery) Query(since *time.Time) string {
+	c := []Criteria{equal("name", b.Name)}
+	if strings.Contains(b.Name, "*") {
+		c[0] = compare("name", "$match", b.Name)
+	}
+
+	if since != nil {
+		c = append(c, greaterThan("started", since.Format(time.RFC3339Nano)))
+	}
+
+	// every promotion of the builds is returned, a criteria on the status would hide later promotions of the build
+	include := `"name", "number", "started"`
+	if b.Status != "" {
+		include += `, "promotion.status", "promotion.created"`
+	}
+
+	return fmt.Sprintf(`builds.find(%s).include(%s).sort({"$asc": ["started"]})`, and(c...), include)
+}
+
+// buildResult is the AQL builds search response
+type buildResult struct {
+	Results []buildItem `json:"results"`
+}
+
+type buildItem struct {
+	Name             string `json:"build.name"`
+	Number           string `json:"build.number"`
+	Started          string `json:"build.started"`
+	PromotionStatus  string `json:"build.promotion.status,omitempty"`
+	PromotionCreated string `json:"build.promotion.created,omitempty"`
+}
+
+// checkBuilds finds builds started after the input version
+func checkBuilds(c *artifactory.Client, s Source, v Version) (CheckResponse, error) {
+	since := v.Started
+	if since == nil || since.IsZero() {
+		var err error
+
+		since, err = initialLookback(s.InitialLookback, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := s.Build.Query(since)
+	log.Println("query:", query)
+
+	data, err := c.AQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result buildResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := result.Results
+	if s.Build.Status != "" {
+		builds, err = latestPromotion(builds, s.Build.Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return processBuilds(builds)
+}
+
+// latestPromotion returns the rows of builds whose latest promotion has the status, builds without promotions are skipped
+func latestPromotion(builds []buildItem, status string) ([]buildItem, error) {
+	latest := map[string]buildItem{}
+	created := map[string]time.Time{}
+
+	for _, b := range builds {
+		if b.PromotionCreated == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, b.PromotionCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		k := b.Name + "\x00" + b.Number
+		if _, ok := latest[k]; !ok || t.After(created[k]) {
+			latest[k] = b
+			created[k] = t
+		}
+	}
+
+	res := []buildItem{}
+	for _, b := range builds {
+		l, ok := latest[b.Name+"\x00"+b.Number]
+		if ok && l.PromotionStatus == status {
+			res = append(res, b)
+		}
+	}
+
+	return res, nil
+}
+
+// processBuilds converts builds to versions, skipping duplicate rows returned for each build promotion
+func processBuilds(builds []buildItem) (CheckResponse, error) {
+	res := CheckResponse{}
+	seen := map[string]bool{}
+
+	for _, b := range builds {
+		k := b.Name + "\x00" + b.Number
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		started, err := time.Parse(time.RFC3339, b.Started)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, Version{BuildName: b.Name, BuildNumber: b.Number, Started: &started})
+	}
+
+	return res, nil
+}
+
+// buildCriteria returns the criteria matching every artifact of the build modules
+func buildCriteria(v Version) Criteria {
+	return and(equal("artifact.module.build.name", v.BuildName), equal("artifact.module.build.number", v.BuildNumber))
+}
+
+// buildStarted returns the start of the build, nil when the build is not found
+func buildStarted(c *artifactory.Client, name, number string) (*time.Time, error) {
+	query := fmt.Sprintf(`builds.find(%s).include("name", "number", "started")`, and(equal("name", name), equal("number", number)))
+	log.Println("query:", query)
+
+	data, err := c.AQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result buildResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	builds, err := processBuilds(result.Results)
+	if err != nil || len(builds) == 0 {
+		return nil, err
+	}
+
+	return builds[0].Started, nil
+}
@@ -0,0 +1,128 @@
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/artifactory-resource/internal"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestBuildQuery(t *testing.T) {
+	since := internal.GetTimePointer(time.Date(2020, time.May, 26, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		description string
+		build       BuildQuery
+		since       *time.Time
+		expected    string
+	}{
+		{
+			description: "name",
+			build:       BuildQuery{Name: "team-pipeline-job"},
+			expected:    `builds.find({"name":"team-pipeline-job"}).include("name", "number", "started").sort({"$asc": ["started"]})`,
+		},
+		{
+			description: "wildcard name with status & started",
+			build:       BuildQuery{Name: "team-pipeline-*", Status: "released"},
+			since:       since,
+			expected:    `builds.find({"$and":[{"name":{"$match":"team-pipeline-*"}},{"started":{"$gt":"2020-05-26T00:00:00Z"}}]}).include("name", "number", "started", "promotion.status", "promotion.created").sort({"$asc": ["started"]})`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			Expect(t, tc.build.Query(tc.since)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestProcessBuilds(t *testing.T) {
+	data := []byte(`{"results": [
+		{"build.name": "myapp", "build.number": "1", "build.started": "2020-05-26T20:00:00.000Z"},
+		{"build.name": "myapp", "build.number": "1", "build.started": "2020-05-26T20:00:00.000Z"},
+		{"build.name": "myapp", "build.number": "2", "build.started": "2020-05-27T20:00:00.000Z"}
+	]}`)
+
+	var result buildResult
+	err := json.Unmarshal(data, &result)
+	Expect(t, err).To(BeNil())
+
+	out, err := processBuilds(result.Results)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal(CheckResponse{
+		{BuildName: "myapp", BuildNumber: "1", Started: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC))},
+		{BuildName: "myapp", BuildNumber: "2", Started: internal.GetTimePointer(time.Date(2020, time.May, 27, 20, 0, 0, 0, time.UTC))},
+	}))
+
+	_, err = processBuilds([]buildItem{{Name: "myapp", Number: "3", Started: "yesterday"}})
+	Expect(t, err).To(Not(BeNil()))
+}
+
+func TestLatestPromotion(t *testing.T) {
+	promotion := func(number, status, created string) buildItem {
+		return buildItem{Name: "myapp", Number: number, Started: "2020-05-26T20:00:00.000Z", PromotionStatus: status, PromotionCreated: created}
+	}
+
+	builds := []buildItem{
+		promotion("1", "released", "2020-05-27T10:00:00.000Z"),
+		promotion("1", "rolled-back", "2020-05-28T10:00:00.000Z"),
+		promotion("2", "rolled-back", "2020-05-27T10:00:00.000Z"),
+		promotion("2", "released", "2020-05-28T10:00:00.000Z"),
+		promotion("3", "", ""),
+		promotion("4", "released", "2020-05-27T10:00:00.000Z"),
+	}
+
+	out, err := latestPromotion(builds, "released")
+	Expect(t, err).To(BeNil())
+
+	numbers := []string{}
+	for _, b := range out {
+		numbers = append(numbers, b.Number)
+	}
+	Expect(t, numbers).To(Equal([]string{"2", "2", "4"}))
+
+	out, err = latestPromotion(builds, "rolled-back")
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(HaveLen(2))
+	Expect(t, out[0].Number).To(Equal("1"))
+
+	_, err = latestPromotion([]buildItem{promotion("5", "released", "yesterday")}, "released")
+	Expect(t, err).To(Not(BeNil()))
+}
+
+func TestBuildVersion(t *testing.T) {
+	v := Version{BuildName: "myapp", BuildNumber: "1"}
+
+	Expect(t, v.Empty()).To(BeFalse())
+	Expect(t, buildCriteria(v).String()).To(Equal(`{"$and":[{"artifact.module.build.name":"myapp"},{"artifact.module.build.number":"1"}]}`))
+}
+
+func TestBuildStarted(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	results := `{"results": [{"build.name": "myapp", "build.number": "7", "build.started": "2020-05-26T20:00:00.000Z"}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(results))
+	}))
+	defer srv.Close()
+
+	c, err := newClient(Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret"})
+	Expect(t, err).To(BeNil())
+
+	started, err := buildStarted(c, "myapp", "7")
+	Expect(t, err).To(BeNil())
+	Expect(t, started).To(Equal(internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC))))
+
+	results = `{"results": []}`
+	started, err = buildStarted(c, "myapp", "8")
+	Expect(t, err).To(BeNil())
+	Expect(t, started).To(BeNil())
+}
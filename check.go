@@ -1,9 +1,15 @@
 package resource
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/digitalocean/artifactory-resource/internal"
+	"github.com/digitalocean/concourse-resource-library/artifactory"
 	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
 )
 
@@ -15,22 +21,67 @@ func Check(req CheckRequest) (CheckResponse, error) {
 		return nil, err
 	}
 
-	req.Source.AQL.SetModifiedTime(req.Version)
+	if !req.Version.Empty() && !resumable(req.Source, req.Version) {
+		// versions returned by puts may lack the time to continue from, the latest version is returned instead of every
+		// version of the initial lookback
+		log.Println("input version has no modified or started time, use latest:", req.Version)
+		req.Version = Version{}
+	}
+
+	if req.Source.Build != nil {
+		res, err := checkBuilds(c, req.Source, req.Version)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		return selectVersions(req.Version, res), nil
+	}
+
+	since, err := modifiedSince(req.Source, req.Version, time.Now())
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	a := req.Source.AQL
+	a.Include = append(append([]string{}, a.Include...), groupIncludes(req.Source)...)
+
+	query, err := a.Query(since)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
 
-	log.Println("query:", req.Source.AQL.Raw)
+	items, err := searchItems(c, query)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
 
-	data, err := c.SearchItems(req.Source.AQL.Raw)
+	var res CheckResponse
+	if req.Source.GroupBy != "" {
+		res, err = groupItems(req.Source, items)
+		res = newGroups(req.Source, req.Version, res)
+	} else {
+		res, err = processItems(items)
+		res = orderVersions(a, res)
+	}
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	res, err := processItems(data)
+	f, err := newSemverFilter(req.Source)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
+	if f != nil {
+		res = f.apply(req.Version, res)
+	}
+
 	res = selectVersions(req.Version, res)
 
 	log.Println("version count in response:", len(res))
@@ -39,15 +90,90 @@ func Check(req CheckRequest) (CheckResponse, error) {
 	return res, nil
 }
 
-func processItems(s []utils.ResultItem) (CheckResponse, error) {
+// resumable reports whether check can continue from the version, build sources continue from the build start & item
+// sources from the item modified time
+func resumable(s Source, v Version) bool {
+	if s.Build != nil {
+		return v.Started != nil && !v.Started.IsZero()
+	}
+
+	return v.Modified != nil && !v.Modified.IsZero()
+}
+
+// modifiedSince returns the modified time new versions must be found after, nil when unbounded
+func modifiedSince(s Source, v Version, now time.Time) (*time.Time, error) {
+	if v.Modified != nil && !v.Modified.IsZero() {
+		return v.Modified, nil
+	}
+
+	return initialLookback(s.InitialLookback, now)
+}
+
+// initialLookback parses the lookback window used when there is no input version
+func initialLookback(l string, now time.Time) (*time.Time, error) {
+	switch l {
+	case "":
+		return internal.GetTimePointer(now.AddDate(-2, 0, 0)), nil
+	case "all":
+		return nil, nil
+	}
+
+	d, err := time.ParseDuration(l)
+	if err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("initial_lookback must be a positive duration: %s", l)
+		}
+
+		return internal.GetTimePointer(now.Add(-d)), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, l)
+	if err != nil {
+		return nil, fmt.Errorf("initial_lookback must be a duration, RFC3339 timestamp or `all`: %s", l)
+	}
+
+	return &t, nil
+}
+
+// searchItems runs an `items.find` AQL query
+func searchItems(c *artifactory.Client, query string) ([]aqlItem, error) {
+	log.Println("query:", query)
+
+	data, err := c.AQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result aqlResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Results, nil
+}
+
+// aqlResult is the AQL search response, including the fields utils.ResultItem does not provide
+type aqlResult struct {
+	Results []aqlItem `json:"results"`
+}
+
+type aqlItem struct {
+	utils.ResultItem
+	Sha256 string `json:"sha256,omitempty"`
+}
+
+func processItems(s []aqlItem) (CheckResponse, error) {
 	var res CheckResponse
 
 	for _, i := range s {
-		v, err := processItem(i)
+		v, err := processItem(i.ResultItem)
 		if err != nil {
 			return nil, err
 		}
 
+		v.Sha256 = i.Sha256
+
 		res = append(res, v)
 	}
 
@@ -62,23 +188,47 @@ func processItem(i utils.ResultItem) (Version, error) {
 		return v, err
 	}
 
-	v = Version{Repo: i.Repo, Path: i.Path, Name: i.Name, Modified: &m}
+	v = Version{Repo: i.Repo, Path: i.Path, Name: i.Name, Modified: &m, Sha1: i.Actual_Sha1}
+
+	if i.Size > 0 {
+		v.Size = strconv.FormatInt(i.Size, 10)
+	}
 
 	return v, nil
 }
 
+// orderVersions returns versions oldest first as Concourse expects, reversing descending queries & sorting
+// by modified time when Artifactory could not sort the query
+func orderVersions(a AQL, res CheckResponse) CheckResponse {
+	if !a.Sorted() {
+		sort.SliceStable(res, func(i, j int) bool {
+			return res[i].Modified.Before(*res[j].Modified)
+		})
+
+		return res
+	}
+
+	if a.Descending() {
+		for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+
+	return res
+}
+
 // selectVersions handles business logic based on input version
 // 	from Concourse and versions found in external resource
 func selectVersions(v Version, res CheckResponse) CheckResponse {
 	// If there are no new but an input version, return the input
-	if len(res) == 0 && v.Repo != "" {
+	if len(res) == 0 && !v.Empty() {
 		log.Println("no new versions, use input version")
 		res = append(res, v)
 
 	}
 
 	// If there are new versions and no input version, return latest new version
-	if len(res) != 0 && v.Repo == "" {
+	if len(res) != 0 && v.Empty() {
 		log.Println("new versions but no input version, use latest")
 		res = CheckResponse{res[len(res)-1]}
 	}
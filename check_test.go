@@ -1,11 +1,16 @@
 package resource
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/digitalocean/artifactory-resource/internal"
 	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
 	. "github.com/poy/onpar/expect"
 	. "github.com/poy/onpar/matchers"
 )
@@ -59,6 +64,27 @@ func TestProcessItem(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			description: "checksum & size",
+			in: utils.ResultItem{
+				Repo:        "artifact-local",
+				Path:        "some/path",
+				Name:        "artifact",
+				Modified:    "2020-05-26T20:00:00.000Z",
+				Actual_Sha1: "1e5dcbb59b753cb1d46e234d8f6180285b8b86ad",
+				Size:        8,
+				Type:        "file",
+			},
+			expected: Version{
+				Repo:     "artifact-local",
+				Path:     "some/path",
+				Name:     "artifact",
+				Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC)),
+				Sha1:     "1e5dcbb59b753cb1d46e234d8f6180285b8b86ad",
+				Size:     "8",
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -199,3 +225,226 @@ func TestSelectVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderVersions(t *testing.T) {
+	older := Version{Repo: "artifact-local", Path: "some/path", Name: "artifact-1", Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC))}
+	newer := Version{Repo: "artifact-local", Path: "some/path", Name: "artifact-2", Modified: internal.GetTimePointer(time.Date(2020, time.May, 27, 20, 0, 0, 0, time.UTC))}
+
+	tests := []struct {
+		description string
+		aql         AQL
+		found       CheckResponse
+		expected    CheckResponse
+	}{
+		{
+			description: "default sort",
+			aql:         AQL{},
+			found:       CheckResponse{older, newer},
+			expected:    CheckResponse{older, newer},
+		},
+		{
+			description: "descending sort",
+			aql:         AQL{Sort: &AQLSort{Field: "modified", Direction: "desc"}},
+			found:       CheckResponse{newer, older},
+			expected:    CheckResponse{older, newer},
+		},
+		{
+			description: "unsorted with properties",
+			aql:         AQL{Include: []string{"property"}},
+			found:       CheckResponse{newer, older},
+			expected:    CheckResponse{older, newer},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out := orderVersions(tc.aql, tc.found)
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestProcessItems(t *testing.T) {
+	data := []byte(`{"results": [{"repo": "artifact-local", "path": "some/path", "name": "artifact", "type": "file", "size": 8, "modified": "2020-05-26T20:00:00.000Z", "actual_sha1": "1e5dcbb59b753cb1d46e234d8f6180285b8b86ad", "sha256": "c7c5c1d70c5dec4416ab6158afd0b223ef40c29b1dc1f97ed9428b94d4cadb1c"}]}`)
+
+	var result aqlResult
+	err := json.Unmarshal(data, &result)
+	Expect(t, err).To(BeNil())
+
+	out, err := processItems(result.Results)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal(CheckResponse{
+		{
+			Repo:     "artifact-local",
+			Path:     "some/path",
+			Name:     "artifact",
+			Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC)),
+			Sha256:   "c7c5c1d70c5dec4416ab6158afd0b223ef40c29b1dc1f97ed9428b94d4cadb1c",
+			Sha1:     "1e5dcbb59b753cb1d46e234d8f6180285b8b86ad",
+			Size:     "8",
+		},
+	}))
+}
+
+func TestModifiedSince(t *testing.T) {
+	now := time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		description string
+		source      Source
+		version     Version
+		expected    *time.Time
+		expectError bool
+	}{
+		{
+			description: "input version",
+			source:      Source{InitialLookback: "all"},
+			version:     Version{Modified: internal.GetTimePointer(time.Date(2020, time.May, 25, 20, 0, 0, 0, time.UTC))},
+			expected:    internal.GetTimePointer(time.Date(2020, time.May, 25, 20, 0, 0, 0, time.UTC)),
+		},
+		{
+			description: "default lookback",
+			source:      Source{},
+			expected:    internal.GetTimePointer(time.Date(2018, time.May, 26, 20, 0, 0, 0, time.UTC)),
+		},
+		{
+			description: "duration lookback",
+			source:      Source{InitialLookback: "720h"},
+			expected:    internal.GetTimePointer(time.Date(2020, time.April, 26, 20, 0, 0, 0, time.UTC)),
+		},
+		{
+			description: "timestamp lookback",
+			source:      Source{InitialLookback: "2015-01-01T00:00:00Z"},
+			expected:    internal.GetTimePointer(time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			description: "all",
+			source:      Source{InitialLookback: "all"},
+			expected:    nil,
+		},
+		{
+			description: "negative duration",
+			source:      Source{InitialLookback: "-24h"},
+			expectError: true,
+		},
+		{
+			description: "invalid",
+			source:      Source{InitialLookback: "last week"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := modifiedSince(tc.source, tc.version, now)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestResumable(t *testing.T) {
+	modified := internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		description string
+		source      Source
+		version     Version
+		expected    bool
+	}{
+		{description: "item version", version: Version{Repo: "artifacts-local", Path: "app", Name: "app.tgz", Modified: modified}, expected: true},
+		{description: "item version without modified", version: Version{Repo: "artifacts-local", Path: "app", Name: "app.tgz"}, expected: false},
+		{description: "promoted build on item source", version: Version{BuildName: "app", BuildNumber: "1", Started: modified}, expected: false},
+		{description: "build version", source: Source{Build: &BuildQuery{Name: "app"}}, version: Version{BuildName: "app", BuildNumber: "1", Started: modified}, expected: true},
+		{description: "build version without started", source: Source{Build: &BuildQuery{Name: "app"}}, version: Version{BuildName: "app", BuildNumber: "1"}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			Expect(t, resumable(tc.source, tc.version)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestCheckUnresumableVersion(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [
+			{"repo": "artifacts-local", "path": "app", "name": "app-1.tgz", "modified": "2020-05-25T20:00:00.000Z"},
+			{"repo": "artifacts-local", "path": "app", "name": "app-2.tgz", "modified": "2020-05-26T20:00:00.000Z"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	req := CheckRequest{
+		Source:  Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret", AQL: AQL{Repo: "artifacts-local"}},
+		Version: Version{BuildName: "app", BuildNumber: "1"},
+	}
+
+	out, err := Check(req)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(HaveLen(1))
+	Expect(t, out[0].Name).To(Equal("app-2.tgz"))
+}
+
+func TestCheckInputVersionGroup(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	input := Version{Repo: "artifacts-local", Path: "app/1", Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 0, 0, 0, time.UTC))}
+
+	tests := []struct {
+		description string
+		source      Source
+		results     string
+		expected    CheckResponse
+	}{
+		{
+			description: "only items added to the input group",
+			source:      Source{GroupBy: GroupByPath},
+			results:     `{"repo": "artifacts-local", "path": "app/1", "name": "sbom.json", "modified": "2020-05-26T20:05:00.000Z"}`,
+			expected:    CheckResponse{input},
+		},
+		{
+			description: "items added to the input group & a new group",
+			source:      Source{GroupBy: GroupByPath},
+			results: `{"repo": "artifacts-local", "path": "app/1", "name": "app.tgz.sha256", "modified": "2020-05-26T20:05:00.000Z"},
+				{"repo": "artifacts-local", "path": "app/2", "name": "app.tgz", "modified": "2020-05-27T20:00:00.000Z"}`,
+			expected: CheckResponse{{Repo: "artifacts-local", Path: "app/2", Modified: internal.GetTimePointer(time.Date(2020, time.May, 27, 20, 0, 0, 0, time.UTC))}},
+		},
+		{
+			description: "build group spanning paths",
+			source:      Source{GroupBy: GroupByBuild},
+			results: `{"repo": "artifacts-local", "path": "app/sbom", "name": "sbom.json", "modified": "2020-05-26T20:05:00.000Z",
+				"properties": [{"key": "build.name", "value": "app"}, {"key": "build.number", "value": "1"}]}`,
+			expected: CheckResponse{{Repo: "artifacts-local", Path: "app/1", Modified: input.Modified, BuildName: "app", BuildNumber: "1"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"results": [` + tc.results + `]}`))
+			}))
+			defer srv.Close()
+
+			v := input
+			if tc.source.GroupBy == GroupByBuild {
+				v.BuildName, v.BuildNumber = "app", "1"
+			}
+
+			tc.source.Endpoint, tc.source.User, tc.source.Password = srv.URL+"/", "ci", "secret"
+			tc.source.AQL = AQL{Repo: "artifacts-local"}
+
+			out, err := Check(CheckRequest{Source: tc.source, Version: v})
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
@@ -1,6 +1,13 @@
 package resource
 
-import "github.com/digitalocean/concourse-resource-library/artifactory"
+import (
+	"strings"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	jfrog "github.com/jfrog/jfrog-client-go/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/auth"
+	"github.com/jfrog/jfrog-client-go/config"
+)
 
 func newClient(s Source) (*artifactory.Client, error) {
 	return artifactory.NewClient(
@@ -8,3 +15,38 @@ func newClient(s Source) (*artifactory.Client, error) {
 		artifactory.Authentication(s.User, s.Password, s.APIKey, s.AccessToken),
 	)
 }
+
+// newServicesManager builds a JFrog services manager for operations the resource library client does not provide,
+// threads defaults to the JFrog client default when 0
+func newServicesManager(s Source, dryRun bool, threads int) (*jfrog.ArtifactoryServicesManager, error) {
+	d := auth.NewArtifactoryDetails()
+
+	endpoint := s.Endpoint
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	d.SetUrl(endpoint)
+
+	switch {
+	case s.AccessToken != "":
+		d.SetAccessToken(s.AccessToken)
+	case s.APIKey != "":
+		d.SetUser(s.User)
+		d.SetApiKey(s.APIKey)
+	default:
+		d.SetUser(s.User)
+		d.SetPassword(s.Password)
+	}
+
+	b := config.NewConfigBuilder().SetServiceDetails(d).SetDryRun(dryRun)
+	if threads > 0 {
+		b.SetThreads(threads)
+	}
+
+	c, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return jfrog.New(&d, c)
+}
@@ -27,6 +27,11 @@ func main() {
 		log.Fatalf("invalid source config: %s", err)
 	}
 
+	err = request.Params.Validate()
+	if err != nil {
+		log.Fatalf("invalid params config: %s", err)
+	}
+
 	if len(os.Args) < 2 {
 		log.Fatalf("missing arguments")
 	}
@@ -44,6 +49,11 @@ func main() {
 		log.Fatalf("failed to write metadata.json: %s", err)
 	}
 
+	err = response.Version.ToFile(filepath.Join(dir, "resource"))
+	if err != nil {
+		log.Fatalf("failed to write version.json: %s", err)
+	}
+
 	err = response.Write()
 	if err != nil {
 		log.Fatalf("failed to write response to stdout: %s", err)
@@ -0,0 +1,133 @@
+package resource
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// collectedPath is the Artifactory `repo/path` the modules of the build are collected to, scoped by the build name &
+// number so modules of earlier builds are never published again
+func collectedPath(base string, b buildinfo.BuildInfo) string {
+	return strings.Trim(base, "/") + "/" + safeName(b.Name) + "/" + safeName(b.Number)
+}
+
+// writeCollected uploads the build info of a collect put to the collected path of the build, files are named after the
+// module ID & their content so concurrent puts never overwrite each other
+func writeCollected(s Source, base, id string, b buildinfo.BuildInfo) (string, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "collect")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	name := fmt.Sprintf("%s-%x.json", safeName(id), sha1.Sum(data))
+
+	p := filepath.Join(dir, name)
+	err = ioutil.WriteFile(p, data, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	sm, err := newServicesManager(s, false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	target := collectedPath(base, b) + "/" + name
+
+	_, uploaded, failed, err := sm.UploadFiles(uploadParams(p, target, artifactory.Properties{}))
+	if err != nil {
+		return "", err
+	}
+
+	if uploaded != 1 || failed > 0 {
+		return "", fmt.Errorf("failed to upload collected build info to %s", target)
+	}
+
+	return target, nil
+}
+
+// readCollected returns the modules collected for the build, merged in file name order, & the collected items
+func readCollected(s Source, base string, b buildinfo.BuildInfo) ([]buildinfo.Module, []aqlItem, error) {
+	c, err := newClient(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, path, _ := itemLocation(collectedPath(base, b) + "/")
+	criteria := and(equal("repo", repo), equal("path", path), compare("name", "$match", "*.json"))
+
+	items, err := searchItems(c, fmt.Sprintf("items.find(%s).include(%s)", criteria, quoteFields(DefaultIncludeFields)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	sm, err := newServicesManager(s, false, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modules := []buildinfo.Module{}
+	for _, i := range items {
+		r, err := sm.ReadRemoteFile(i.GetItemRelativePath())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var collected buildinfo.BuildInfo
+		err = json.NewDecoder(r).Decode(&collected)
+		r.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid collected build info %s: %s", i.GetItemRelativePath(), err)
+		}
+
+		modules = mergeModules(modules, collected.Modules...)
+	}
+
+	return modules, items, nil
+}
+
+// deleteCollected deletes the collected items once their modules are published
+func deleteCollected(s Source, items []aqlItem) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	sm, err := newServicesManager(s, false, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	targets := []utils.ResultItem{}
+	for _, i := range items {
+		targets = append(targets, i.ResultItem)
+	}
+
+	return sm.DeleteFiles(targets)
+}
+
+func safeName(s string) string {
+	return unsafeFileChars.ReplaceAllString(s, "_")
+}
@@ -0,0 +1,135 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+// fakeStorage stores uploaded files & answers the AQL searches of a single path
+type fakeStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+var aqlPath = regexp.MustCompile(`"path":"([^"]*)"`)
+
+func (f *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/")
+
+	switch {
+	case r.Method == http.MethodPost && p == "api/search/aql":
+		query, _ := ioutil.ReadAll(r.Body)
+		m := aqlPath.FindStringSubmatch(string(query))
+
+		results := []map[string]string{}
+		for k := range f.files {
+			parts := strings.SplitN(k, "/", 2)
+			if m != nil && path.Dir(parts[1]) != m[1] {
+				continue
+			}
+
+			results = append(results, map[string]string{"repo": parts[0], "path": path.Dir(parts[1]), "name": path.Base(parts[1]), "type": "file"})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	case r.Method == http.MethodPut:
+		f.files[p], _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && f.files[p] != nil:
+		w.Write(f.files[p])
+	case r.Method == http.MethodDelete && f.files[p] != nil:
+		delete(f.files, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeStorage) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	paths := []string{}
+	for k := range f.files {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+func TestCollect(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	storage := &fakeStorage{files: map[string][]byte{}}
+	srv := httptest.NewServer(storage)
+	defer srv.Close()
+
+	s := Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret"}
+
+	artifact := func(name string) buildinfo.Artifact {
+		return buildinfo.Artifact{Name: name, Path: "artifacts-local/app/" + name, Checksum: &buildinfo.Checksum{Sha1: name}}
+	}
+
+	builds := []struct {
+		number string
+		module buildinfo.Module
+	}{
+		{number: "42", module: buildinfo.Module{Id: "team/app", Artifacts: []buildinfo.Artifact{artifact("app-linux")}}},
+		{number: "42", module: buildinfo.Module{Id: "docs", Artifacts: []buildinfo.Artifact{artifact("docs.tgz")}}},
+		{number: "42", module: buildinfo.Module{Id: "team/app", Artifacts: []buildinfo.Artifact{artifact("app-darwin")}}},
+		// modules collected by an earlier build are never published again
+		{number: "41", module: buildinfo.Module{Id: "team/app", Artifacts: []buildinfo.Artifact{artifact("app-stale")}}},
+	}
+
+	for _, b := range builds {
+		p, err := writeCollected(s, "build-info-local/collected/", b.module.Id, buildinfo.BuildInfo{Name: "team pipeline", Number: b.number, Modules: []buildinfo.Module{b.module}})
+		Expect(t, err).To(BeNil())
+		Expect(t, strings.HasPrefix(p, fmt.Sprintf("build-info-local/collected/team_pipeline/%s/%s-", b.number, safeName(b.module.Id)))).To(BeTrue())
+	}
+	Expect(t, storage.paths()).To(HaveLen(4))
+
+	modules, items, err := readCollected(s, "build-info-local/collected", buildinfo.BuildInfo{Name: "team pipeline", Number: "42"})
+	Expect(t, err).To(BeNil())
+	Expect(t, items).To(HaveLen(3))
+	Expect(t, modules).To(HaveLen(2))
+	Expect(t, modules[0]).To(Equal(buildinfo.Module{Id: "docs", Artifacts: []buildinfo.Artifact{artifact("docs.tgz")}}))
+	Expect(t, modules[1].Id).To(Equal("team/app"))
+
+	names := []string{}
+	for _, a := range modules[1].Artifacts {
+		names = append(names, a.Name)
+	}
+	sort.Strings(names)
+	Expect(t, names).To(Equal([]string{"app-darwin", "app-linux"}))
+
+	n, err := deleteCollected(s, items)
+	Expect(t, err).To(BeNil())
+	Expect(t, n).To(Equal(3))
+
+	paths := storage.paths()
+	Expect(t, paths).To(HaveLen(1))
+	Expect(t, strings.HasPrefix(paths[0], "build-info-local/collected/team_pipeline/41/")).To(BeTrue())
+
+	modules, items, err = readCollected(s, "build-info-local/collected", buildinfo.BuildInfo{Name: "team pipeline", Number: "43"})
+	Expect(t, err).To(BeNil())
+	Expect(t, modules).To(HaveLen(0))
+	Expect(t, items).To(HaveLen(0))
+}
@@ -0,0 +1,220 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	meta "github.com/digitalocean/concourse-resource-library/metadata"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+// RetentionParameters select the items of an AQL result to delete, items are deleted when matching every rule set
+type RetentionParameters struct {
+	KeepLast       int      `json:"keep_last,omitempty"`       // KeepLast number of the most recently modified items kept per path
+	OlderThan      string   `json:"older_than,omitempty"`      // OlderThan duration, e.g. `720h`, only items modified before it are deleted
+	KeepProperties []string `json:"keep_properties,omitempty"` // KeepProperties keeps every item with any of the properties set
+}
+
+// Validate the retention parameters
+func (r *RetentionParameters) Validate() error {
+	switch {
+	case r.KeepLast < 0:
+		return fmt.Errorf("keep_last must not be negative: %d", r.KeepLast)
+	case r.KeepLast == 0 && r.OlderThan == "":
+		return errors.New("retention requires keep_last or older_than")
+	}
+
+	if r.OlderThan != "" {
+		d, err := time.ParseDuration(r.OlderThan)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("older_than must be a positive duration: %s", r.OlderThan)
+		}
+	}
+
+	return nil
+}
+
+// deleteItems deletes the version found in From, or the items of the AQL result outside of the retention policy,
+// every item is reported before it is deleted
+func deleteItems(req PutRequest, dir string) (GetResponse, error) {
+	get := GetResponse{
+		Version:  Version{},
+		Metadata: meta.Metadata{},
+	}
+
+	c, err := newClient(req.Source)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	var items, kept []aqlItem
+
+	switch {
+	case req.Params.From != "" && req.Params.AQL != nil:
+		err = errors.New("from & aql cannot be combined")
+	case req.Params.From != "":
+		var v Version
+		v, err = ReadVersion(filepath.Join(dir, req.Params.From))
+		if err == nil {
+			items, err = versionItems(c, req.Source, v)
+		}
+	case req.Params.AQL != nil:
+		err = req.Params.Retention.Validate()
+		if err != nil {
+			break
+		}
+
+		a := *req.Params.AQL
+		if len(req.Params.Retention.KeepProperties) > 0 {
+			a.Include = append(append([]string{}, a.Include...), "property")
+		}
+
+		err = validateDeleteAQL(&a)
+		if err != nil {
+			break
+		}
+
+		var query string
+		query, err = a.Query(nil)
+		if err == nil {
+			items, err = searchItems(c, query)
+		}
+		if err == nil {
+			items, kept, err = retain(items, req.Params.Retention, time.Now())
+		}
+	default:
+		err = errors.New("delete mode requires from or aql")
+	}
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	action := "deleting"
+	if req.Params.DryRun {
+		action = "dry run, would delete"
+	}
+
+	targets := []utils.ResultItem{}
+	for _, i := range items {
+		targets = append(targets, i.ResultItem)
+		rlog.StdErr(action, i.GetItemRelativePath())
+	}
+	for _, i := range kept {
+		rlog.StdErr("keeping", i.GetItemRelativePath())
+	}
+	rlog.StdErr("delete report", fmt.Sprintf("%d to delete, %d kept", len(targets), len(kept)))
+
+	deleted := 0
+	if !req.Params.DryRun && len(targets) > 0 {
+		sm, err := newServicesManager(req.Source, false, 0)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		deleted, err = sm.DeleteFiles(targets)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+		rlog.StdErr("deleted", deleted)
+	}
+
+	get.Metadata.Add("matched", fmt.Sprint(len(targets)))
+	get.Metadata.Add("deleted", fmt.Sprint(deleted))
+	get.Metadata.Add("kept", fmt.Sprint(len(kept)))
+	if req.Params.DryRun {
+		get.Metadata.Add("dry-run", "true")
+	}
+
+	return get, nil
+}
+
+// validateDeleteAQL ensures the AQL of a delete is valid & limited to a repository, an empty AQL matches every item
+// of the instance
+func validateDeleteAQL(a *AQL) error {
+	err := a.Validate()
+	if err != nil {
+		return err
+	}
+
+	c, err := a.Criteria()
+	if err != nil {
+		return err
+	}
+
+	if !namesRepo(c) {
+		return fmt.Errorf("delete aql must name a repo without wildcards: %s", c)
+	}
+
+	return nil
+}
+
+// retain splits items into those to delete & those kept by the retention policy, items are ranked per path by modified time
+func retain(items []aqlItem, r RetentionParameters, now time.Time) ([]aqlItem, []aqlItem, error) {
+	var cutoff *time.Time
+	if r.OlderThan != "" {
+		d, err := time.ParseDuration(r.OlderThan)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		t := now.Add(-d)
+		cutoff = &t
+	}
+
+	modified := make([]time.Time, len(items))
+	for i, item := range items {
+		m, err := time.Parse(time.RFC3339, item.Modified)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		modified[i] = m
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return modified[order[i]].After(modified[order[j]])
+	})
+
+	remove, keep := []aqlItem{}, []aqlItem{}
+	seen := map[string]int{}
+
+	for _, i := range order {
+		item := items[i]
+		p := item.Repo + "/" + item.Path
+		seen[p]++
+
+		switch {
+		case seen[p] <= r.KeepLast,
+			cutoff != nil && !modified[i].Before(*cutoff),
+			hasAnyProperty(item.Properties, r.KeepProperties):
+			keep = append(keep, item)
+		default:
+			remove = append(remove, item)
+		}
+	}
+
+	return remove, keep, nil
+}
+
+func hasAnyProperty(props []utils.Property, keys []string) bool {
+	for _, p := range props {
+		if contains(keys, p.Key) {
+			return true
+		}
+	}
+
+	return false
+}
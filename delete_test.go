@@ -0,0 +1,182 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestRetain(t *testing.T) {
+	now := time.Date(2020, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	item := func(path, name, modified string, props ...utils.Property) aqlItem {
+		return aqlItem{ResultItem: utils.ResultItem{Repo: "artifacts-local", Path: path, Name: name, Modified: modified, Properties: props}}
+	}
+
+	items := []aqlItem{
+		item("a", "1", "2020-06-01T00:00:00.000Z"),
+		item("a", "2", "2020-06-10T00:00:00.000Z"),
+		item("a", "3", "2020-06-20T00:00:00.000Z"),
+		item("a", "4", "2020-06-29T00:00:00.000Z"),
+		item("b", "1", "2020-05-01T00:00:00.000Z", utils.Property{Key: "release", Value: "true"}),
+		item("b", "2", "2020-06-25T00:00:00.000Z"),
+	}
+
+	tests := []struct {
+		description string
+		retention   RetentionParameters
+		remove      []string
+		expectError bool
+	}{
+		{
+			description: "keep last",
+			retention:   RetentionParameters{KeepLast: 2},
+			remove:      []string{"a/2", "a/1"},
+		},
+		{
+			description: "older than",
+			retention:   RetentionParameters{OlderThan: "240h"},
+			remove:      []string{"a/2", "a/1", "b/1"},
+		},
+		{
+			description: "keep last & older than",
+			retention:   RetentionParameters{KeepLast: 2, OlderThan: "360h"},
+			remove:      []string{"a/2", "a/1"},
+		},
+		{
+			description: "keep properties",
+			retention:   RetentionParameters{KeepLast: 1, KeepProperties: []string{"release"}},
+			remove:      []string{"a/3", "a/2", "a/1"},
+		},
+		{
+			description: "invalid older than",
+			retention:   RetentionParameters{OlderThan: "30d"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			remove, keep, err := retain(items, tc.retention, now)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+
+			out := []string{}
+			for _, i := range remove {
+				out = append(out, i.Path+"/"+i.Name)
+			}
+
+			Expect(t, out).To(Equal(tc.remove))
+			Expect(t, len(keep)+len(remove)).To(Equal(len(items)))
+		})
+	}
+}
+
+func TestRetentionParametersValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		retention   RetentionParameters
+		expectError bool
+	}{
+		{
+			description: "keep last",
+			retention:   RetentionParameters{KeepLast: 5},
+		},
+		{
+			description: "older than",
+			retention:   RetentionParameters{OlderThan: "720h"},
+		},
+		{
+			description: "no rule",
+			retention:   RetentionParameters{KeepProperties: []string{"release"}},
+			expectError: true,
+		},
+		{
+			description: "negative keep last",
+			retention:   RetentionParameters{KeepLast: -1},
+			expectError: true,
+		},
+		{
+			description: "negative older than",
+			retention:   RetentionParameters{OlderThan: "-1h"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := tc.retention.Validate()
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
+
+func TestValidateDeleteAQL(t *testing.T) {
+	tests := []struct {
+		description string
+		aql         AQL
+		expectError bool
+	}{
+		{
+			description: "repo",
+			aql:         AQL{Repo: "snapshots-local", Path: "myapplication/*"},
+		},
+		{
+			description: "raw repo",
+			aql:         AQL{Raw: `{"repo": "snapshots-local", "path": {"$match": "myapplication/*"}}`},
+		},
+		{
+			description: "raw repo equal within and",
+			aql:         AQL{Raw: `{"$and": [{"repo": {"$eq": "snapshots-local"}}, {"name": {"$match": "*.tgz"}}]}`},
+		},
+		{
+			description: "empty",
+			aql:         AQL{},
+			expectError: true,
+		},
+		{
+			description: "path only",
+			aql:         AQL{Path: "myapplication/*"},
+			expectError: true,
+		},
+		{
+			description: "repo wildcard",
+			aql:         AQL{Raw: `{"repo": {"$match": "*"}}`},
+			expectError: true,
+		},
+		{
+			description: "repo within or",
+			aql:         AQL{Raw: `{"$or": [{"repo": "snapshots-local"}, {"name": "app.tgz"}]}`},
+			expectError: true,
+		},
+		{
+			description: "properties with limit",
+			aql:         AQL{Repo: "snapshots-local", Include: []string{"property"}, Limit: 10},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateDeleteAQL(&tc.aql)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+)
+
+var sha1Regex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// dependencies returns the build dependencies of the versions fetched by prior gets & the checksum manifest
+func dependencies(s Source, params PutParameters, dir string) ([]buildinfo.Dependency, error) {
+	deps := []buildinfo.Dependency{}
+
+	for _, input := range params.Dependencies {
+		d, err := versionDependencies(s, filepath.Join(dir, input))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dependencies of %s: %s", input, err)
+		}
+
+		deps = append(deps, d...)
+	}
+
+	if params.DependencyManifest != "" {
+		d, err := readManifest(filepath.Join(dir, params.DependencyManifest))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dependency manifest: %s", err)
+		}
+
+		deps = append(deps, d...)
+	}
+
+	return deps, nil
+}
+
+// versionDependencies returns the dependencies of the version written by a get to the input, build versions depend on
+// every artifact of the build
+func versionDependencies(s Source, input string) ([]buildinfo.Dependency, error) {
+	v, err := ReadVersion(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if singleItem(s, v) && v.Name != "" {
+		sha1 := v.Sha1
+		if sha1 == "" {
+			// versions found before checksums were part of the version keep the sha1 in the get metadata
+			data, err := ioutil.ReadFile(filepath.Join(input, "resource", "sha1"))
+			if err != nil {
+				return nil, err
+			}
+
+			sha1 = strings.TrimSpace(string(data))
+		}
+
+		return []buildinfo.Dependency{dependency(v.Name, sha1, "")}, nil
+	}
+
+	c, err := newClient(s)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := versionItems(c, s, v)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := []buildinfo.Dependency{}
+	for _, i := range items {
+		deps = append(deps, dependency(i.Name, i.Actual_Sha1, i.Actual_Md5))
+	}
+
+	return deps, nil
+}
+
+// readManifest reads dependencies from a manifest in the `sha1sum` output format, `<sha1>  <name>` per line
+func readManifest(p string) ([]buildinfo.Dependency, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	deps := []buildinfo.Dependency{}
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !sha1Regex.MatchString(fields[0]) {
+			return nil, fmt.Errorf("line %d must be a sha1 & name: %s", n, line)
+		}
+
+		// sha1sum marks files read in binary mode with `*`
+		deps = append(deps, dependency(path.Base(strings.TrimPrefix(fields[1], "*")), strings.ToLower(fields[0]), ""))
+	}
+
+	return deps, scanner.Err()
+}
+
+// dependency returns the build dependency of an artifact, typed by its extension like build artifacts
+func dependency(name, sha1, md5 string) buildinfo.Dependency {
+	d := buildinfo.Dependency{Id: name, Checksum: &buildinfo.Checksum{Sha1: sha1, Md5: md5}}
+	if i := strings.LastIndex(name, "."); i != -1 {
+		d.Type = name[i+1:]
+	}
+
+	return d
+}
+
+// addDependencies adds the dependencies to the module with the ID, adding the module when missing
+func addDependencies(modules []buildinfo.Module, id string, deps []buildinfo.Dependency) []buildinfo.Module {
+	if len(deps) == 0 {
+		return modules
+	}
+
+	return mergeModules(modules, buildinfo.Module{Id: id, Dependencies: deps})
+}
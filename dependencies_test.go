@@ -0,0 +1,112 @@
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		description string
+		manifest    string
+		expected    []buildinfo.Dependency
+		expectError bool
+	}{
+		{
+			description: "sha1sum output",
+			manifest: "# vendored dependencies\n" +
+				"3A1A2B3C4D5E6F708192A3B4C5D6E7F8091A2B3C  vendor/lib-1.2.0.jar\n" +
+				"\n" +
+				"0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c *vendor/tool\n",
+			expected: []buildinfo.Dependency{
+				{Id: "lib-1.2.0.jar", Type: "jar", Checksum: &buildinfo.Checksum{Sha1: "3a1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"}},
+				{Id: "tool", Checksum: &buildinfo.Checksum{Sha1: "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c"}},
+			},
+		},
+		{
+			description: "empty manifest",
+			expected:    []buildinfo.Dependency{},
+		},
+		{
+			description: "sha256 checksum",
+			manifest:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  lib.jar\n",
+			expectError: true,
+		},
+		{
+			description: "missing name",
+			manifest:    "3a1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c\n",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "manifest")
+			Expect(t, err).To(BeNil())
+			defer os.Remove(f.Name())
+
+			_, err = f.WriteString(tc.manifest)
+			Expect(t, err).To(BeNil())
+			f.Close()
+
+			out, err := readManifest(f.Name())
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestVersionDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dependencies")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	inputs := map[string]Version{
+		"checksum":    {Repo: "artifacts-local", Path: "lib", Name: "lib-1.2.0.tgz", Sha1: "3a1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"},
+		"no-checksum": {Repo: "artifacts-local", Path: "tool", Name: "tool"},
+	}
+
+	for input, v := range inputs {
+		err = os.MkdirAll(filepath.Join(dir, input, "resource"), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = v.ToFile(filepath.Join(dir, input, "resource"))
+		Expect(t, err).To(BeNil())
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "no-checksum", "resource", "sha1"), []byte("0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c"), 0644)
+	Expect(t, err).To(BeNil())
+
+	out, err := dependencies(Source{}, PutParameters{Dependencies: []string{"checksum", "no-checksum"}}, dir)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal([]buildinfo.Dependency{
+		{Id: "lib-1.2.0.tgz", Type: "tgz", Checksum: &buildinfo.Checksum{Sha1: "3a1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"}},
+		{Id: "tool", Checksum: &buildinfo.Checksum{Sha1: "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c"}},
+	}))
+
+	_, err = dependencies(Source{}, PutParameters{Dependencies: []string{"missing"}}, dir)
+	Expect(t, err).To(Not(BeNil()))
+}
+
+func TestAddDependencies(t *testing.T) {
+	deps := []buildinfo.Dependency{{Id: "lib.jar", Type: "jar", Checksum: &buildinfo.Checksum{Sha1: "abc"}}}
+
+	modules := []buildinfo.Module{{Id: "charts"}}
+	modules = addDependencies(modules, "app", deps)
+	Expect(t, modules).To(HaveLen(2))
+	Expect(t, modules[1].Id).To(Equal("app"))
+	Expect(t, modules[1].Dependencies).To(Equal(deps))
+
+	Expect(t, addDependencies(modules, "app", nil)).To(Equal(modules))
+}
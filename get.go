@@ -1,9 +1,22 @@
 package resource
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"hash"
+	"io"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
 )
 
 // Get performs the get operation for the resource
@@ -21,21 +34,58 @@ func Get(req GetRequest, dir string) (GetResponse, error) {
 	}
 
 	log.Println(dir)
-	log.Println("version pattern:", req.Version.Pattern())
 
-	artifacts, err := c.DownloadItems(req.Version.Pattern(), dir+string(os.PathSeparator))
+	patterns, err := versionPatterns(c, req)
 	if err != nil {
 		log.Println(err)
 		return res, err
 	}
 
+	patterns = filterPatterns(patterns, req.Params.Include, req.Params.Exclude)
+
+	artifacts := []artifactory.Artifact{}
+	for _, p := range patterns {
+		log.Println("version pattern:", p)
+
+		a, err := c.DownloadItems(p, dir+string(os.PathSeparator))
+		if err != nil {
+			log.Println(err)
+			return res, err
+		}
+
+		artifacts = append(artifacts, a...)
+	}
+
 	if len(artifacts) == 0 {
 		err := errors.New("no artifacts found")
 		log.Println(err)
 		return res, err
 	}
 
+	orderArtifacts(req.Version, artifacts)
+
+	err = verify(req.Version, artifacts[0].File.LocalPath)
+	if err != nil {
+		log.Println(err)
+		return res, err
+	}
+
+	err = placeArtifacts(dir, req.Version, req.Params, artifacts)
+	if err != nil {
+		log.Println(err)
+		return res, err
+	}
+
+	if req.Params.Unpack {
+		err = unpackArtifacts(filepath.Join(dir, req.Params.Directory), req.Params.DeleteArchive, artifacts)
+		if err != nil {
+			log.Println(err)
+			return res, err
+		}
+	}
+
 	a := artifacts[0]
+
 	res = GetResponse{
 		Version:  req.Version,
 		Metadata: metadata(a),
@@ -43,3 +93,292 @@ func Get(req GetRequest, dir string) (GetResponse, error) {
 
 	return res, nil
 }
+
+// versionPatterns returns the pattern of every item of the version matching the property filters
+func versionPatterns(c *artifactory.Client, req GetRequest) ([]string, error) {
+	if singleItem(req.Source, req.Version) && len(req.Params.Properties) == 0 {
+		return []string{req.Version.Pattern()}, nil
+	}
+
+	criteria, err := versionCriteria(req.Source, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return itemPatterns(c, and(criteria, propertiesCriteria(req.Params.Properties)))
+}
+
+// singleItem reports whether the version is a single item rather than a build or a group of items
+func singleItem(s Source, v Version) bool {
+	return s.Build == nil && s.GroupBy == "" && v.Repo != ""
+}
+
+// versionCriteria returns the criteria matching every item of the version
+func versionCriteria(s Source, v Version) (Criteria, error) {
+	switch {
+	case s.Build != nil, v.Repo == "" && v.BuildName != "":
+		// build versions are also returned by promote puts of item sources
+		return buildCriteria(v), nil
+	case s.GroupBy != "":
+		source, err := s.AQL.Criteria()
+		if err != nil {
+			return nil, err
+		}
+
+		return and(source, groupCriteria(s, v)), nil
+	case v.Repo == "" || v.Path == "" || v.Name == "":
+		return nil, fmt.Errorf("version has no item, group or build: %+v", v)
+	}
+
+	return and(equal("repo", v.Repo), equal("path", v.Path), equal("name", v.Name)), nil
+}
+
+// filterPatterns returns the patterns matching an include glob (when defined) & no exclude glob, globs are
+// matched against the artifact name or the full `repo/path/name` when containing `/`
+func filterPatterns(patterns, include, exclude []string) []string {
+	if len(include) == 0 && len(exclude) == 0 {
+		return patterns
+	}
+
+	out := []string{}
+	for _, p := range patterns {
+		if len(include) > 0 && !matchAny(include, p) {
+			log.Println("skipping artifact not included:", p)
+			continue
+		}
+
+		if matchAny(exclude, p) {
+			log.Println("skipping excluded artifact:", p)
+			continue
+		}
+
+		out = append(out, p)
+	}
+
+	return out
+}
+
+func matchAny(globs []string, p string) bool {
+	for _, g := range globs {
+		target := path.Base(p)
+		if strings.Contains(g, "/") {
+			target = p
+		}
+
+		ok, _ := path.Match(g, target)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// itemPatterns returns the pattern of every item matching the criteria
+func itemPatterns(c *artifactory.Client, criteria Criteria) ([]string, error) {
+	query := fmt.Sprintf("items.find(%s).include(%s)", criteria, quoteFields(DefaultIncludeFields))
+
+	items, err := searchItems(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := []string{}
+	for _, i := range items {
+		patterns = append(patterns, i.GetItemRelativePath())
+	}
+
+	return patterns, nil
+}
+
+// orderArtifacts sorts the artifacts by their Artifactory path with the artifact named by the version first, AQL
+// results of grouped & build versions are unordered
+func orderArtifacts(v Version, artifacts []artifactory.Artifact) {
+	named := func(a artifactory.Artifact) bool {
+		return v.Name != "" && path.Base(a.File.ArtifactoryPath) == v.Name
+	}
+
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		if named(artifacts[i]) != named(artifacts[j]) {
+			return named(artifacts[i])
+		}
+
+		return artifacts[i].File.ArtifactoryPath < artifacts[j].File.ArtifactoryPath
+	})
+}
+
+// primaryArtifact returns the index of the artifact to rename, the only artifact downloaded or the artifact named by
+// the version
+func primaryArtifact(v Version, artifacts []artifactory.Artifact) (int, error) {
+	if len(artifacts) == 1 {
+		return 0, nil
+	}
+
+	primary := -1
+	for i, a := range artifacts {
+		if v.Name == "" || filepath.Base(a.File.LocalPath) != v.Name {
+			continue
+		}
+
+		if primary >= 0 {
+			return 0, fmt.Errorf("rename matches several artifacts named %s", v.Name)
+		}
+		primary = i
+	}
+
+	if primary < 0 {
+		return 0, fmt.Errorf("rename requires a single artifact or a version naming the primary artifact, downloaded %d artifacts", len(artifacts))
+	}
+
+	return primary, nil
+}
+
+// placeArtifacts moves downloaded artifacts into the configured directory, flattening their Artifactory path
+// & renaming the primary artifact when configured, the local path of each artifact is updated in place
+func placeArtifacts(dir string, v Version, params GetParameters, artifacts []artifactory.Artifact) error {
+	if !params.Flat && params.Directory == "" && params.Rename == "" {
+		return nil
+	}
+
+	primary := -1
+	if params.Rename != "" {
+		var err error
+
+		primary, err = primaryArtifact(v, artifacts)
+		if err != nil {
+			return err
+		}
+	}
+
+	base := filepath.Join(dir, params.Directory)
+	placed := map[string]bool{}
+
+	for i := range artifacts {
+		src := artifacts[i].File.LocalPath
+
+		rel, err := filepath.Rel(dir, src)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(base, rel)
+		if params.Flat {
+			dst = filepath.Join(base, filepath.Base(src))
+		}
+		if i == primary {
+			dst = filepath.Join(filepath.Dir(dst), params.Rename)
+		}
+
+		if placed[dst] {
+			return fmt.Errorf("multiple artifacts would be placed at %s", dst)
+		}
+		placed[dst] = true
+
+		if dst == src {
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(src, dst)
+		if err != nil {
+			return err
+		}
+
+		log.Println("placed artifact:", dst)
+		artifacts[i].File.LocalPath = dst
+
+		removeEmptyDirs(filepath.Dir(src), dir)
+	}
+
+	return nil
+}
+
+// unpackArtifacts extracts every supported archive into dir, optionally deleting the archive afterwards
+func unpackArtifacts(dir string, del bool, artifacts []artifactory.Artifact) error {
+	for _, a := range artifacts {
+		ok, err := unpack(a.File.LocalPath, dir)
+		if err != nil {
+			return err
+		}
+
+		if ok && del {
+			err = os.Remove(a.File.LocalPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes empty directories from path up to, but not including, stop
+func removeEmptyDirs(path, stop string) {
+	for path != stop && strings.HasPrefix(path, stop) {
+		err := os.Remove(path)
+		if err != nil {
+			return
+		}
+
+		path = filepath.Dir(path)
+	}
+}
+
+// verify ensures the downloaded file matches the checksums & size of the version found by check
+func verify(v Version, path string) error {
+	if v.Size != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if strconv.FormatInt(info.Size(), 10) != v.Size {
+			return fmt.Errorf("size mismatch for %s: expected %s, downloaded %d", path, v.Size, info.Size())
+		}
+	}
+
+	checks := []struct {
+		name     string
+		expected string
+		hash     hash.Hash
+	}{
+		{name: "sha1", expected: v.Sha1, hash: sha1.New()},
+		{name: "sha256", expected: v.Sha256, hash: sha256.New()},
+	}
+
+	for _, c := range checks {
+		if c.expected == "" {
+			continue
+		}
+
+		sum, err := checksum(path, c.hash)
+		if err != nil {
+			return err
+		}
+
+		if sum != c.expected {
+			return fmt.Errorf("%s mismatch for %s: expected %s, downloaded %s", c.name, path, c.expected, sum)
+		}
+	}
+
+	return nil
+}
+
+func checksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,348 @@
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifactory-resource")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "artifact")
+	err = ioutil.WriteFile(path, []byte("artifact"), 0644)
+	Expect(t, err).To(BeNil())
+
+	tests := []struct {
+		description string
+		version     Version
+		expectError bool
+	}{
+		{
+			description: "no checksums",
+			version:     Version{},
+			expectError: false,
+		},
+		{
+			description: "matching checksums & size",
+			version: Version{
+				Sha1:   "1e5dcbb59b753cb1d46e234d8f6180285b8b86ad",
+				Sha256: "c7c5c1d70c5dec4416ab6158afd0b223ef40c29b1dc1f97ed9428b94d4cadb1c",
+				Size:   "8",
+			},
+			expectError: false,
+		},
+		{
+			description: "size mismatch",
+			version:     Version{Size: "9"},
+			expectError: true,
+		},
+		{
+			description: "sha1 mismatch",
+			version:     Version{Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			expectError: true,
+		},
+		{
+			description: "sha256 mismatch",
+			version:     Version{Sha256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := verify(tc.version, path)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
+
+func TestFilterPatterns(t *testing.T) {
+	patterns := []string{
+		"artifact-local/myapp/1/myapp-linux-amd64.tgz",
+		"artifact-local/myapp/1/myapp-linux-arm64.tgz",
+		"artifact-local/myapp/1/myapp-darwin-amd64.tgz",
+		"artifact-local/myapp/1/myapp-linux-amd64.tgz.sha256",
+	}
+
+	tests := []struct {
+		description string
+		include     []string
+		exclude     []string
+		expected    []string
+	}{
+		{
+			description: "no filters",
+			expected:    patterns,
+		},
+		{
+			description: "include",
+			include:     []string{"*-linux-amd64*"},
+			expected: []string{
+				"artifact-local/myapp/1/myapp-linux-amd64.tgz",
+				"artifact-local/myapp/1/myapp-linux-amd64.tgz.sha256",
+			},
+		},
+		{
+			description: "include & exclude",
+			include:     []string{"*-linux-*"},
+			exclude:     []string{"*.sha256"},
+			expected: []string{
+				"artifact-local/myapp/1/myapp-linux-amd64.tgz",
+				"artifact-local/myapp/1/myapp-linux-arm64.tgz",
+			},
+		},
+		{
+			description: "full path glob",
+			include:     []string{"artifact-local/myapp/*/myapp-darwin-*"},
+			expected: []string{
+				"artifact-local/myapp/1/myapp-darwin-amd64.tgz",
+			},
+		},
+		{
+			description: "everything excluded",
+			exclude:     []string{"*"},
+			expected:    []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out := filterPatterns(patterns, tc.include, tc.exclude)
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestGetParametersValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		params      GetParameters
+		expectError bool
+	}{
+		{description: "empty", params: GetParameters{}},
+		{description: "valid", params: GetParameters{Include: []string{"*.tgz"}, Properties: map[string]PropertyCriteria{"os": {Equal: "linux"}}}},
+		{description: "invalid glob", params: GetParameters{Exclude: []string{"[.tgz"}}, expectError: true},
+		{description: "empty property", params: GetParameters{Properties: map[string]PropertyCriteria{"os": {}}}, expectError: true},
+		{description: "directory", params: GetParameters{Directory: "artifacts/myapp", Rename: "myapp.tgz"}},
+		{description: "directory outside destination", params: GetParameters{Directory: "../artifacts"}, expectError: true},
+		{description: "rename with path", params: GetParameters{Rename: "bin/myapp"}, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := tc.params.Validate()
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
+
+func TestPlaceArtifacts(t *testing.T) {
+	tests := []struct {
+		description string
+		params      GetParameters
+		version     Version
+		files       []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			description: "unchanged",
+			params:      GetParameters{},
+			files:       []string{"myapp/1/myapp.tgz", "myapp/1/myapp.tgz.sha256"},
+			expected:    []string{"myapp/1/myapp.tgz", "myapp/1/myapp.tgz.sha256"},
+		},
+		{
+			description: "flat",
+			params:      GetParameters{Flat: true},
+			files:       []string{"myapp/1/myapp.tgz", "myapp/1/myapp.tgz.sha256"},
+			expected:    []string{"myapp.tgz", "myapp.tgz.sha256"},
+		},
+		{
+			description: "directory",
+			params:      GetParameters{Directory: "artifacts"},
+			files:       []string{"myapp/1/myapp.tgz"},
+			expected:    []string{"artifacts/myapp/1/myapp.tgz"},
+		},
+		{
+			description: "flat directory & rename",
+			params:      GetParameters{Flat: true, Directory: "artifacts", Rename: "app.tgz"},
+			version:     Version{Repo: "artifacts-local", Path: "myapp/1", Name: "myapp-1.0.0.tgz"},
+			files:       []string{"myapp/1/myapp-1.0.0.tgz", "myapp/1/myapp-1.0.0.tgz.sha256"},
+			expected:    []string{"artifacts/app.tgz", "artifacts/myapp-1.0.0.tgz.sha256"},
+		},
+		{
+			description: "rename artifact named by the version",
+			params:      GetParameters{Flat: true, Rename: "app.tgz"},
+			version:     Version{Repo: "artifacts-local", Path: "myapp/1", Name: "myapp-1.0.0.tgz"},
+			files:       []string{"myapp/1/myapp-1.0.0.tgz.sha256", "myapp/1/myapp-1.0.0.tgz"},
+			expected:    []string{"myapp-1.0.0.tgz.sha256", "app.tgz"},
+		},
+		{
+			description: "rename single artifact",
+			params:      GetParameters{Rename: "app.tgz"},
+			version:     Version{BuildName: "myapp", BuildNumber: "1"},
+			files:       []string{"myapp/1/myapp-1.0.0.tgz"},
+			expected:    []string{"myapp/1/app.tgz"},
+		},
+		{
+			description: "rename without primary artifact",
+			params:      GetParameters{Rename: "app.tgz"},
+			version:     Version{Repo: "artifacts-local", Path: "myapp/1"},
+			files:       []string{"myapp/1/myapp-1.0.0.tgz", "myapp/1/myapp-1.0.0.tgz.sha256"},
+			expectError: true,
+		},
+		{
+			description: "flat collision",
+			params:      GetParameters{Flat: true},
+			files:       []string{"myapp/linux/myapp", "myapp/darwin/myapp"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "artifactory-resource")
+			Expect(t, err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			artifacts := []artifactory.Artifact{}
+			for _, f := range tc.files {
+				p := filepath.Join(dir, f)
+				Expect(t, os.MkdirAll(filepath.Dir(p), os.ModePerm)).To(BeNil())
+				Expect(t, ioutil.WriteFile(p, []byte(f), 0644)).To(BeNil())
+
+				artifacts = append(artifacts, artifactory.Artifact{File: utils.FileInfo{LocalPath: p}})
+			}
+
+			err = placeArtifacts(dir, tc.version, tc.params, artifacts)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+
+			for i, e := range tc.expected {
+				p := filepath.Join(dir, e)
+				Expect(t, artifacts[i].File.LocalPath).To(Equal(p))
+
+				data, err := ioutil.ReadFile(p)
+				Expect(t, err).To(BeNil())
+				Expect(t, string(data)).To(Equal(tc.files[i]))
+			}
+
+			if tc.params.Flat {
+				_, err = os.Stat(filepath.Join(dir, "myapp"))
+				Expect(t, os.IsNotExist(err)).To(BeTrue())
+			}
+		})
+	}
+}
+
+func TestOrderArtifacts(t *testing.T) {
+	artifact := func(p string) artifactory.Artifact {
+		return artifactory.Artifact{File: utils.FileInfo{ArtifactoryPath: p}}
+	}
+
+	artifacts := []artifactory.Artifact{
+		artifact("artifacts-local/myapp/1/myapp.tgz.sha256"),
+		artifact("artifacts-local/myapp/1/myapp-linux"),
+		artifact("artifacts-local/myapp/1/myapp.tgz"),
+	}
+
+	orderArtifacts(Version{Repo: "artifacts-local", Path: "myapp/1", Name: "myapp.tgz"}, artifacts)
+	Expect(t, artifacts).To(Equal([]artifactory.Artifact{
+		artifact("artifacts-local/myapp/1/myapp.tgz"),
+		artifact("artifacts-local/myapp/1/myapp-linux"),
+		artifact("artifacts-local/myapp/1/myapp.tgz.sha256"),
+	}))
+
+	orderArtifacts(Version{Repo: "artifacts-local", Path: "myapp/1"}, artifacts)
+	Expect(t, artifacts).To(Equal([]artifactory.Artifact{
+		artifact("artifacts-local/myapp/1/myapp-linux"),
+		artifact("artifacts-local/myapp/1/myapp.tgz"),
+		artifact("artifacts-local/myapp/1/myapp.tgz.sha256"),
+	}))
+}
+
+func TestVersionCriteria(t *testing.T) {
+	source := AQL{Repo: "artifacts-local"}
+
+	tests := []struct {
+		description string
+		source      Source
+		version     Version
+		expected    Criteria
+		expectError bool
+	}{
+		{
+			description: "item",
+			version:     Version{Repo: "artifacts-local", Path: "myapp", Name: "myapp.tgz"},
+			expected:    and(equal("repo", "artifacts-local"), equal("path", "myapp"), equal("name", "myapp.tgz")),
+		},
+		{
+			description: "grouped by path",
+			source:      Source{AQL: source, GroupBy: GroupByPath},
+			version:     Version{Repo: "artifacts-local", Path: "myapp/1"},
+			expected:    and(equal("repo", "artifacts-local"), equal("repo", "artifacts-local"), equal("path", "myapp/1")),
+		},
+		{
+			description: "grouped by build",
+			source:      Source{AQL: source, GroupBy: GroupByBuild},
+			version:     Version{Repo: "artifacts-local", Path: "myapp/1", BuildName: "myapp", BuildNumber: "1"},
+			expected:    and(equal("repo", "artifacts-local"), equal("@build.name", "myapp"), equal("@build.number", "1")),
+		},
+		{
+			description: "build source",
+			source:      Source{Build: &BuildQuery{Name: "myapp"}},
+			version:     Version{BuildName: "myapp", BuildNumber: "1"},
+			expected:    buildCriteria(Version{BuildName: "myapp", BuildNumber: "1"}),
+		},
+		{
+			description: "promoted build of an item source",
+			version:     Version{BuildName: "myapp", BuildNumber: "1"},
+			expected:    buildCriteria(Version{BuildName: "myapp", BuildNumber: "1"}),
+		},
+		{
+			description: "item without name",
+			version:     Version{Repo: "artifacts-local", Path: "myapp"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := versionCriteria(tc.source, tc.version)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out.String()).To(Equal(tc.expected.String()))
+		})
+	}
+}
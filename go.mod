@@ -5,11 +5,12 @@ go 1.14
 require (
 	github.com/Masterminds/goutils v1.1.0 // indirect
 	github.com/Masterminds/semver v1.5.0 // indirect
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/Masterminds/sprig v2.22.0+incompatible // indirect
 	github.com/digitalocean/concourse-resource-library v0.0.0-20200611211633-2ca0343261f6
 	github.com/fatih/color v1.9.0 // indirect
 	github.com/go-bindata/go-bindata v3.1.2+incompatible // indirect
-	github.com/go-git/go-git/v5 v5.0.0 // indirect
+	github.com/go-git/go-git/v5 v5.0.0
 	github.com/google/go-github v17.0.0+incompatible // indirect
 	github.com/google/uuid v1.1.1 // indirect
 	github.com/huandu/xstrings v1.3.1 // indirect
@@ -19,5 +20,6 @@ require (
 	github.com/poy/onpar v0.0.0-20200406201722-06f95a1c68e8
 	github.com/spf13/cobra v1.0.0 // indirect
 	github.com/telia-oss/github-pr-resource v0.19.1 // indirect
+	github.com/ulikunitz/xz v0.5.6
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d // indirect
 )
@@ -0,0 +1,166 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+// Grouping modes collapsing multiple items into a single version
+const (
+	GroupByPath     = "path"
+	GroupByBuild    = "build"
+	GroupByProperty = "property"
+)
+
+// validateGroup ensures the grouping configuration is complete
+func validateGroup(s Source) error {
+	switch s.GroupBy {
+	case "", GroupByPath, GroupByBuild:
+	case GroupByProperty:
+		if s.GroupProperty == "" {
+			return errors.New("group_property is required when grouping by property")
+		}
+	default:
+		return fmt.Errorf("unsupported group_by: %s", s.GroupBy)
+	}
+
+	if s.GroupBy != "" && (s.VersionRegex != "" || s.VersionConstraint != "") {
+		return errors.New("group_by cannot be combined with version_regex or version_constraint")
+	}
+
+	if s.GroupBy == GroupByBuild || s.GroupBy == GroupByProperty {
+		if s.AQL.Sort != nil || s.AQL.Limit > 0 {
+			return errors.New("aql sort & limit cannot be used when grouping by build or property")
+		}
+	}
+
+	return nil
+}
+
+// groupIncludes returns the properties the query must include to group items
+func groupIncludes(s Source) []string {
+	switch s.GroupBy {
+	case GroupByBuild:
+		return []string{"@build.name", "@build.number"}
+	case GroupByProperty:
+		return []string{"@" + s.GroupProperty}
+	}
+
+	return nil
+}
+
+// groupItems collapses items sharing a path, build or property value into a single version, the most recently
+// modified item of each group provides the repo, path & modified time of the version
+func groupItems(s Source, items []aqlItem) (CheckResponse, error) {
+	keys := []string{}
+	groups := map[string]Version{}
+
+	for _, i := range items {
+		item, err := processItem(i.ResultItem)
+		if err != nil {
+			return nil, err
+		}
+
+		v := Version{Repo: item.Repo, Path: item.Path, Modified: item.Modified}
+
+		switch s.GroupBy {
+		case GroupByPath:
+		case GroupByBuild:
+			v.BuildName = property(i.Properties, "build.name")
+			v.BuildNumber = property(i.Properties, "build.number")
+			if v.BuildName == "" || v.BuildNumber == "" {
+				log.Println("skipping item without build properties:", i.GetItemRelativePath())
+				continue
+			}
+		case GroupByProperty:
+			v.Group = property(i.Properties, s.GroupProperty)
+			if v.Group == "" {
+				log.Println("skipping item without group property:", i.GetItemRelativePath())
+				continue
+			}
+		}
+
+		k := groupKey(s, v)
+
+		g, ok := groups[k]
+		if !ok {
+			keys = append(keys, k)
+			groups[k] = v
+			continue
+		}
+
+		if v.Modified.After(*g.Modified) {
+			groups[k] = v
+		}
+	}
+
+	res := CheckResponse{}
+	for _, k := range keys {
+		res = append(res, groups[k])
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Modified.Before(*res[j].Modified)
+	})
+
+	return res, nil
+}
+
+// newGroups drops the group of the input version, items added to the group after the input version was found
+// belong to the same version rather than a new one
+func newGroups(s Source, v Version, res CheckResponse) CheckResponse {
+	if v.Empty() {
+		return res
+	}
+
+	k := groupKey(s, v)
+
+	out := CheckResponse{}
+	for _, g := range res {
+		if groupKey(s, g) == k {
+			log.Println("skipping items added to the input version group:", k)
+			continue
+		}
+
+		out = append(out, g)
+	}
+
+	return out
+}
+
+// groupCriteria returns the criteria matching every item within the group of the version
+func groupCriteria(s Source, v Version) Criteria {
+	switch s.GroupBy {
+	case GroupByBuild:
+		return and(equal("@build.name", v.BuildName), equal("@build.number", v.BuildNumber))
+	case GroupByProperty:
+		return equal("@"+s.GroupProperty, v.Group)
+	}
+
+	return and(equal("repo", v.Repo), equal("path", v.Path))
+}
+
+func groupKey(s Source, v Version) string {
+	switch s.GroupBy {
+	case GroupByBuild:
+		return v.BuildName + "\x00" + v.BuildNumber
+	case GroupByProperty:
+		return v.Group
+	}
+
+	return v.Repo + "/" + v.Path
+}
+
+func property(props []utils.Property, key string) string {
+	for _, p := range props {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/artifactory-resource/internal"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestGroupItems(t *testing.T) {
+	item := func(path, name, modified string, props ...utils.Property) aqlItem {
+		return aqlItem{ResultItem: utils.ResultItem{Repo: "artifact-local", Path: path, Name: name, Modified: modified, Properties: props, Type: "file"}}
+	}
+
+	build := func(number string) []utils.Property {
+		return []utils.Property{{Key: "build.name", Value: "myapp"}, {Key: "build.number", Value: number}}
+	}
+
+	items := []aqlItem{
+		item("myapp/1", "myapp.tgz", "2020-05-26T20:00:00.000Z", build("1")...),
+		item("myapp/1", "myapp.tgz.sha256", "2020-05-26T20:01:00.000Z", build("1")...),
+		item("myapp/2", "myapp.tgz", "2020-05-27T20:00:00.000Z", build("2")...),
+		item("myapp/1", "sbom.json", "2020-05-26T20:02:00.000Z", append(build("1"), utils.Property{Key: "release.id", Value: "r1"})...),
+		item("myapp/3", "myapp.tgz", "2020-05-25T20:00:00.000Z", utils.Property{Key: "release.id", Value: "r0"}),
+	}
+
+	tests := []struct {
+		description string
+		source      Source
+		expected    CheckResponse
+	}{
+		{
+			description: "group by path",
+			source:      Source{GroupBy: GroupByPath},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp/3", Modified: internal.GetTimePointer(time.Date(2020, time.May, 25, 20, 0, 0, 0, time.UTC))},
+				{Repo: "artifact-local", Path: "myapp/1", Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 2, 0, 0, time.UTC))},
+				{Repo: "artifact-local", Path: "myapp/2", Modified: internal.GetTimePointer(time.Date(2020, time.May, 27, 20, 0, 0, 0, time.UTC))},
+			},
+		},
+		{
+			description: "group by build",
+			source:      Source{GroupBy: GroupByBuild},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp/1", Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 2, 0, 0, time.UTC)), BuildName: "myapp", BuildNumber: "1"},
+				{Repo: "artifact-local", Path: "myapp/2", Modified: internal.GetTimePointer(time.Date(2020, time.May, 27, 20, 0, 0, 0, time.UTC)), BuildName: "myapp", BuildNumber: "2"},
+			},
+		},
+		{
+			description: "group by property",
+			source:      Source{GroupBy: GroupByProperty, GroupProperty: "release.id"},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp/3", Modified: internal.GetTimePointer(time.Date(2020, time.May, 25, 20, 0, 0, 0, time.UTC)), Group: "r0"},
+				{Repo: "artifact-local", Path: "myapp/1", Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 20, 2, 0, 0, time.UTC)), Group: "r1"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := groupItems(tc.source, items)
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestGroupCriteria(t *testing.T) {
+	tests := []struct {
+		description string
+		source      Source
+		version     Version
+		expected    string
+	}{
+		{
+			description: "path",
+			source:      Source{GroupBy: GroupByPath},
+			version:     Version{Repo: "artifact-local", Path: "myapp/1"},
+			expected:    `{"$and":[{"repo":"artifact-local"},{"path":"myapp/1"}]}`,
+		},
+		{
+			description: "build",
+			source:      Source{GroupBy: GroupByBuild},
+			version:     Version{Repo: "artifact-local", Path: "myapp/1", BuildName: "myapp", BuildNumber: "1"},
+			expected:    `{"$and":[{"@build.name":"myapp"},{"@build.number":"1"}]}`,
+		},
+		{
+			description: "property",
+			source:      Source{GroupBy: GroupByProperty, GroupProperty: "release.id"},
+			version:     Version{Repo: "artifact-local", Path: "myapp/1", Group: "r1"},
+			expected:    `{"@release.id":"r1"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out := groupCriteria(tc.source, tc.version)
+			Expect(t, out.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestValidateGroup(t *testing.T) {
+	tests := []struct {
+		description string
+		source      Source
+		expectError bool
+	}{
+		{description: "no grouping", source: Source{}},
+		{description: "path", source: Source{GroupBy: GroupByPath, AQL: AQL{Sort: &AQLSort{Field: "name"}}}},
+		{description: "build", source: Source{GroupBy: GroupByBuild}},
+		{description: "property", source: Source{GroupBy: GroupByProperty, GroupProperty: "release.id"}},
+		{description: "property without name", source: Source{GroupBy: GroupByProperty}, expectError: true},
+		{description: "unsupported", source: Source{GroupBy: "name"}, expectError: true},
+		{description: "semver", source: Source{GroupBy: GroupByPath, VersionRegex: "(.*)"}, expectError: true},
+		{description: "build with limit", source: Source{GroupBy: GroupByBuild, AQL: AQL{Limit: 10}}, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateGroup(tc.source)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
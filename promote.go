@@ -0,0 +1,189 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	meta "github.com/digitalocean/concourse-resource-library/metadata"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+// PromoteParameters for promoting a build between repositories
+type PromoteParameters struct {
+	TargetRepo          string `json:"target_repo"`                    // TargetRepo to promote the build artifacts to
+	SourceRepo          string `json:"source_repo,omitempty"`          // SourceRepo limits the promotion to artifacts within the repository
+	Status              string `json:"status,omitempty"`               // Status of the promotion, e.g. `released`
+	Comment             string `json:"comment,omitempty"`              // Comment for the promotion
+	Copy                bool   `json:"copy,omitempty"`                 // Copy artifacts to the target instead of moving them
+	IncludeDependencies bool   `json:"include_dependencies,omitempty"` // IncludeDependencies promotes the build dependencies as well
+	Properties          string `json:"properties,omitempty"`           // Properties is path to file containing properties set on promoted artifacts in `key=value\n` form
+}
+
+// promote promotes the build of the current job, or the build of the version found in From, to the target repository
+func promote(req PutRequest, dir string) (GetResponse, error) {
+	get := GetResponse{
+		Version:  Version{},
+		Metadata: meta.Metadata{},
+	}
+
+	if req.Params.Promote.TargetRepo == "" {
+		err := errors.New("promote requires a target_repo")
+		log.Println(err)
+		return get, err
+	}
+
+	name, err := buildName(req.Params, dir)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	number, err := buildNumber(req.Params, dir)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	if req.Params.From != "" {
+		v, err := ReadVersion(filepath.Join(dir, req.Params.From))
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		name, number, err = versionBuild(req.Source, v)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+	}
+
+	p := services.NewPromotionParams()
+	p.BuildName = name
+	p.BuildNumber = number
+	p.TargetRepo = req.Params.Promote.TargetRepo
+	p.SourceRepo = req.Params.Promote.SourceRepo
+	p.Status = req.Params.Promote.Status
+	p.Comment = req.Params.Promote.Comment
+	p.Copy = req.Params.Promote.Copy
+	p.IncludeDependencies = req.Params.Promote.IncludeDependencies
+
+	if req.Params.Promote.Properties != "" {
+		props := artifactory.Properties{}
+		err := props.FromFile(filepath.Join(dir, req.Params.Promote.Properties))
+		if err != nil {
+			rlog.StdErr("failed to read properties file", err)
+		}
+
+		p.Properties = props.String()
+	}
+
+	sm, err := newServicesManager(req.Source, req.Params.DryRun, 0)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	err = sm.PromoteBuild(p)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+	rlog.StdErr("build promoted", []string{name, number, p.TargetRepo})
+
+	get.Metadata.Add("build-name", name)
+	get.Metadata.Add("build-number", number)
+	get.Metadata.Add("target-repo", p.TargetRepo)
+	get.Metadata.Add("status", p.Status)
+
+	// a dry run returns an empty version like transfer dry runs, the build was never promoted
+	if req.Params.DryRun {
+		get.Metadata.Add("dry-run", "true")
+		return get, nil
+	}
+
+	c, err := newClient(req.Source)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	// check continues from the build start, a version without it would return every build of the initial lookback
+	started, err := buildStarted(c, name, number)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	get.Version = Version{BuildName: name, BuildNumber: number, Started: started}
+
+	return get, nil
+}
+
+// versionBuild returns the build of a version, read from the `build.name` & `build.number` properties of the items of
+// item & grouped versions
+func versionBuild(s Source, v Version) (string, string, error) {
+	if v.BuildName != "" && v.BuildNumber != "" {
+		return v.BuildName, v.BuildNumber, nil
+	}
+
+	criteria, err := versionCriteria(s, v)
+	if err != nil {
+		return "", "", err
+	}
+
+	c, err := newClient(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	fields := append(append([]string{}, DefaultIncludeFields...), "@build.name", "@build.number")
+
+	items, err := searchItems(c, fmt.Sprintf("items.find(%s).include(%s)", criteria, quoteFields(fields)))
+	if err != nil {
+		return "", "", err
+	}
+
+	return itemsBuild(v, items)
+}
+
+// itemsBuild returns the single build the items of a version were published by, items without build properties are
+// skipped
+func itemsBuild(v Version, items []aqlItem) (string, string, error) {
+	var name, number string
+
+	for _, i := range items {
+		n, b, err := itemBuild(v, i.Properties)
+		if err != nil {
+			log.Println("skipping item without build properties:", i.GetItemRelativePath())
+			continue
+		}
+
+		if name != "" && (n != name || b != number) {
+			return "", "", fmt.Errorf("version items were published by several builds: %s %s & %s %s", name, number, n, b)
+		}
+
+		name, number = n, b
+	}
+
+	if name == "" {
+		return "", "", fmt.Errorf("version has no items with build properties: %+v", v)
+	}
+
+	return name, number, nil
+}
+
+// itemBuild returns the build an item was published by from its properties
+func itemBuild(v Version, props []utils.Property) (string, string, error) {
+	name, number := property(props, "build.name"), property(props, "build.number")
+	if name == "" || number == "" {
+		return "", "", fmt.Errorf("version has no build properties: %s", v.Pattern())
+	}
+
+	return name, number, nil
+}
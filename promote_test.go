@@ -0,0 +1,187 @@
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestItemBuild(t *testing.T) {
+	tests := []struct {
+		description string
+		props       []utils.Property
+		name        string
+		number      string
+		expectError bool
+	}{
+		{
+			description: "build properties",
+			props: []utils.Property{
+				{Key: "build.name", Value: "team-pipeline-job"},
+				{Key: "build.number", Value: "42"},
+				{Key: "vcs.revision", Value: "abc123"},
+			},
+			name:   "team-pipeline-job",
+			number: "42",
+		},
+		{
+			description: "missing build number",
+			props:       []utils.Property{{Key: "build.name", Value: "team-pipeline-job"}},
+			expectError: true,
+		},
+		{
+			description: "no properties",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			name, number, err := itemBuild(Version{Repo: "artifacts-local", Path: "project", Name: "artifact"}, tc.props)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, name).To(Equal(tc.name))
+			Expect(t, number).To(Equal(tc.number))
+		})
+	}
+}
+
+func TestVersionBuild(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	name, number, err := versionBuild(Source{}, Version{BuildName: "team-pipeline-job", BuildNumber: "42"})
+	Expect(t, err).To(BeNil())
+	Expect(t, name).To(Equal("team-pipeline-job"))
+	Expect(t, number).To(Equal("42"))
+
+	build := func(number string) string {
+		return `"properties": [{"key": "build.name", "value": "team-pipeline-job"}, {"key": "build.number", "value": "` + number + `"}]`
+	}
+
+	tests := []struct {
+		description string
+		source      Source
+		version     Version
+		results     string
+		query       string
+		number      string
+		expectError bool
+	}{
+		{
+			description: "item version",
+			version:     Version{Repo: "artifacts-local", Path: "app/1", Name: "app.tgz"},
+			results:     `{"repo": "artifacts-local", "path": "app/1", "name": "app.tgz", ` + build("1") + `}`,
+			query:       `{"$and":[{"repo":"artifacts-local"},{"path":"app/1"},{"name":"app.tgz"}]}`,
+			number:      "1",
+		},
+		{
+			description: "path group with a checksum without build properties",
+			source:      Source{GroupBy: GroupByPath, AQL: AQL{Repo: "artifacts-local"}},
+			version:     Version{Repo: "artifacts-local", Path: "app/2"},
+			results: `{"repo": "artifacts-local", "path": "app/2", "name": "app.tgz", ` + build("2") + `},
+				{"repo": "artifacts-local", "path": "app/2", "name": "app.tgz.sha256"}`,
+			query:  `{"path":"app/2"}`,
+			number: "2",
+		},
+		{
+			description: "property group of several builds",
+			source:      Source{GroupBy: GroupByProperty, GroupProperty: "release.id", AQL: AQL{Repo: "artifacts-local"}},
+			version:     Version{Repo: "artifacts-local", Path: "app/3", Group: "r3"},
+			results: `{"repo": "artifacts-local", "path": "app/3", "name": "app.tgz", ` + build("3") + `},
+				{"repo": "artifacts-local", "path": "app/4", "name": "app.tgz", ` + build("4") + `}`,
+			query:       `{"@release.id":"r3"}`,
+			expectError: true,
+		},
+		{
+			description: "path group without build properties",
+			source:      Source{GroupBy: GroupByPath, AQL: AQL{Repo: "artifacts-local"}},
+			version:     Version{Repo: "artifacts-local", Path: "app/5"},
+			results:     `{"repo": "artifacts-local", "path": "app/5", "name": "app.tgz"}`,
+			query:       `{"path":"app/5"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var query string
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				query = string(body)
+
+				w.Write([]byte(`{"results": [` + tc.results + `]}`))
+			}))
+			defer srv.Close()
+
+			tc.source.Endpoint, tc.source.User, tc.source.Password = srv.URL+"/", "ci", "secret"
+
+			name, number, err := versionBuild(tc.source, tc.version)
+			Expect(t, strings.Contains(query, tc.query)).To(BeTrue())
+			Expect(t, strings.Contains(query, `"@build.name", "@build.number"`)).To(BeTrue())
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, name).To(Equal("team-pipeline-job"))
+			Expect(t, number).To(Equal(tc.number))
+		})
+	}
+}
+
+func TestPromoteDryRun(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	var mu sync.Mutex
+	requests := map[string]string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		requests[r.Method+" "+r.URL.Path] = string(body)
+	}))
+	defer srv.Close()
+
+	req := PutRequest{
+		Source: Source{Endpoint: srv.URL, User: "ci", Password: "secret"},
+		Params: PutParameters{
+			Mode:        ModePromote,
+			BuildName:   "team-pipeline-job",
+			BuildNumber: "42",
+			DryRun:      true,
+			Promote:     PromoteParameters{TargetRepo: "releases-local", Status: "released"},
+		},
+	}
+
+	out, err := Put(req, "")
+	Expect(t, err).To(BeNil())
+	Expect(t, out.Version).To(Equal(Version{}))
+
+	dryRun := ""
+	for _, f := range out.Metadata {
+		if f.Name == "dry-run" {
+			dryRun = f.Value
+		}
+	}
+	Expect(t, dryRun).To(Equal("true"))
+
+	// the build start is never looked up for the empty version
+	Expect(t, requests).To(HaveLen(1))
+	Expect(t, strings.Contains(requests["POST /api/build/promote/team-pipeline-job/42"], `"dryRun":true`)).To(BeTrue())
+}
@@ -0,0 +1,215 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	meta "github.com/digitalocean/concourse-resource-library/metadata"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+// updateProperties sets & deletes properties on the items of the version found in From or matching the AQL
+func updateProperties(req PutRequest, dir string) (GetResponse, error) {
+	get := GetResponse{
+		Version:  Version{},
+		Metadata: meta.Metadata{},
+	}
+
+	if req.Params.Properties == "" && len(req.Params.DeleteProperties) == 0 {
+		err := errors.New("properties mode requires properties or delete_properties")
+		log.Println(err)
+		return get, err
+	}
+
+	props := artifactory.Properties{}
+	if req.Params.Properties != "" {
+		err := props.FromFile(filepath.Join(dir, req.Params.Properties))
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+	}
+
+	c, err := newClient(req.Source)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	v, items, err := propertyTargets(c, req, dir)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	if req.Params.Recursive {
+		items, err = expandFolders(c, items)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+	} else {
+		items = skipFolders(items)
+	}
+
+	if len(items) == 0 {
+		err = errors.New("no items found to update properties")
+		log.Println(err)
+		return get, err
+	}
+
+	targets := []utils.ResultItem{}
+	for _, i := range items {
+		targets = append(targets, i.ResultItem)
+		rlog.StdErr("updating properties", i.GetItemRelativePath())
+	}
+
+	if req.Params.DryRun {
+		rlog.StdErr("dry run, properties not updated", []string{props.String(), strings.Join(req.Params.DeleteProperties, ",")})
+	} else {
+		sm, err := newServicesManager(req.Source, false, 0)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		if len(props) > 0 {
+			n, err := sm.SetProps(services.PropsParams{Items: targets, Props: props.String()})
+			if err != nil {
+				log.Println(err)
+				return get, err
+			}
+			rlog.StdErr("properties set", n)
+		}
+
+		if len(req.Params.DeleteProperties) > 0 {
+			n, err := sm.DeleteProps(services.PropsParams{Items: targets, Props: strings.Join(req.Params.DeleteProperties, ",")})
+			if err != nil {
+				log.Println(err)
+				return get, err
+			}
+			rlog.StdErr("properties deleted", n)
+		}
+	}
+
+	get.Version = v
+	if get.Version.Empty() {
+		get.Version, err = processItem(items[0].ResultItem)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+		get.Version.Sha256 = items[0].Sha256
+	}
+
+	get.Metadata.Add("items", fmt.Sprint(len(targets)))
+	get.Metadata.Add("properties", props.String())
+	get.Metadata.Add("deleted-properties", strings.Join(req.Params.DeleteProperties, ","))
+
+	return get, nil
+}
+
+// propertyTargets returns the version found in From & its items, or the items matching the AQL
+func propertyTargets(c *artifactory.Client, req PutRequest, dir string) (Version, []aqlItem, error) {
+	var v Version
+
+	switch {
+	case req.Params.From != "" && req.Params.AQL != nil:
+		return v, nil, errors.New("from & aql cannot be combined")
+	case req.Params.From != "":
+		v, err := ReadVersion(filepath.Join(dir, req.Params.From))
+		if err != nil {
+			return v, nil, err
+		}
+
+		items, err := versionItems(c, req.Source, v)
+		return v, items, err
+	case req.Params.AQL != nil:
+		err := req.Params.AQL.Validate()
+		if err != nil {
+			return v, nil, err
+		}
+
+		query, err := req.Params.AQL.Query(nil)
+		if err != nil {
+			return v, nil, err
+		}
+
+		items, err := searchItems(c, query)
+		return v, items, err
+	}
+
+	return v, nil, errors.New("properties mode requires from or aql")
+}
+
+// versionItems returns the item of an item version, or every item of a grouped or build version matching the
+// criteria of get
+func versionItems(c *artifactory.Client, s Source, v Version) ([]aqlItem, error) {
+	if singleItem(s, v) {
+		if v.Path == "" || v.Name == "" {
+			return nil, fmt.Errorf("version has no item, group or build: %+v", v)
+		}
+
+		return []aqlItem{{ResultItem: utils.ResultItem{Repo: v.Repo, Path: v.Path, Name: v.Name, Type: "file"}}}, nil
+	}
+
+	criteria, err := versionCriteria(s, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchItems(c, fmt.Sprintf("items.find(%s).include(%s)", criteria, quoteFields(DefaultIncludeFields)))
+}
+
+// expandFolders replaces folder items with every file within them
+func expandFolders(c *artifactory.Client, items []aqlItem) ([]aqlItem, error) {
+	out := []aqlItem{}
+
+	for _, i := range items {
+		if i.Type != "folder" {
+			out = append(out, i)
+			continue
+		}
+
+		files, err := searchItems(c, fmt.Sprintf("items.find(%s).include(%s)", folderCriteria(i.ResultItem), quoteFields(DefaultIncludeFields)))
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, files...)
+	}
+
+	return out, nil
+}
+
+// skipFolders removes folder items, Artifactory applies property changes to folders recursively
+func skipFolders(items []aqlItem) []aqlItem {
+	out := []aqlItem{}
+
+	for _, i := range items {
+		if i.Type == "folder" {
+			log.Println("skipping folder, set recursive to update its items:", i.GetItemRelativePath())
+			continue
+		}
+
+		out = append(out, i)
+	}
+
+	return out
+}
+
+// folderCriteria matches every file within the folder & its sub-folders
+func folderCriteria(i utils.ResultItem) Criteria {
+	p := i.Name
+	if i.Path != "." && i.Path != "" {
+		p = i.Path + "/" + i.Name
+	}
+
+	return and(equal("repo", i.Repo), Criteria{"$or": []Criteria{equal("path", p), compare("path", "$match", p+"/*")}})
+}
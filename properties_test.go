@@ -0,0 +1,141 @@
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestFolderCriteria(t *testing.T) {
+	tests := []struct {
+		description string
+		item        utils.ResultItem
+		expected    string
+	}{
+		{
+			description: "nested folder",
+			item:        utils.ResultItem{Repo: "artifacts-local", Path: "project", Name: "1.0.0", Type: "folder"},
+			expected:    `{"$and":[{"repo":"artifacts-local"},{"$or":[{"path":"project/1.0.0"},{"path":{"$match":"project/1.0.0/*"}}]}]}`,
+		},
+		{
+			description: "root folder",
+			item:        utils.ResultItem{Repo: "artifacts-local", Path: ".", Name: "project", Type: "folder"},
+			expected:    `{"$and":[{"repo":"artifacts-local"},{"$or":[{"path":"project"},{"path":{"$match":"project/*"}}]}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			Expect(t, folderCriteria(tc.item).String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestSkipFolders(t *testing.T) {
+	items := []aqlItem{
+		{ResultItem: utils.ResultItem{Repo: "artifacts-local", Path: "project", Name: "1.0.0", Type: "folder"}},
+		{ResultItem: utils.ResultItem{Repo: "artifacts-local", Path: "project/1.0.0", Name: "artifact", Type: "file"}},
+	}
+
+	out := skipFolders(items)
+	Expect(t, out).To(HaveLen(1))
+	Expect(t, out[0].Name).To(Equal("artifact"))
+}
+
+func TestPropertyTargets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "properties")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "input", "resource"), os.ModePerm)
+	Expect(t, err).To(BeNil())
+
+	v := Version{Repo: "artifacts-local", Path: "project", Name: "artifact"}
+	err = v.ToFile(filepath.Join(dir, "input", "resource"))
+	Expect(t, err).To(BeNil())
+
+	tests := []struct {
+		description string
+		params      PutParameters
+		expected    []aqlItem
+		expectError bool
+	}{
+		{
+			description: "item version",
+			params:      PutParameters{From: "input"},
+			expected:    []aqlItem{{ResultItem: utils.ResultItem{Repo: "artifacts-local", Path: "project", Name: "artifact", Type: "file"}}},
+		},
+		{
+			description: "from & aql",
+			params:      PutParameters{From: "input", AQL: &AQL{Repo: "artifacts-local"}},
+			expectError: true,
+		},
+		{
+			description: "missing version",
+			params:      PutParameters{From: "missing"},
+			expectError: true,
+		},
+		{
+			description: "no target",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, items, err := propertyTargets(nil, PutRequest{Params: tc.params}, dir)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(v))
+			Expect(t, items).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestVersionItems(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	var query string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		query = string(data)
+
+		w.Write([]byte(`{"results": [
+			{"repo": "artifacts-local", "path": "myapp/1", "name": "myapp.tgz", "type": "file"},
+			{"repo": "artifacts-local", "path": "myapp/1", "name": "myapp.tgz.sha256", "type": "file"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	c, err := newClient(Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret"})
+	Expect(t, err).To(BeNil())
+
+	grouped := Source{AQL: AQL{Repo: "artifacts-local"}, GroupBy: GroupByPath}
+
+	items, err := versionItems(c, grouped, Version{Repo: "artifacts-local", Path: "myapp/1"})
+	Expect(t, err).To(BeNil())
+	Expect(t, items).To(HaveLen(2))
+	Expect(t, query).To(Equal(`items.find({"$and":[{"repo":"artifacts-local"},{"repo":"artifacts-local"},{"path":"myapp/1"}]}).include("name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created")`))
+
+	query = ""
+	items, err = versionItems(c, Source{}, Version{Repo: "artifacts-local", Path: "myapp/1", Name: "myapp.tgz"})
+	Expect(t, err).To(BeNil())
+	Expect(t, items).To(Equal([]aqlItem{{ResultItem: utils.ResultItem{Repo: "artifacts-local", Path: "myapp/1", Name: "myapp.tgz", Type: "file"}}}))
+	Expect(t, query).To(Equal(""))
+
+	_, err = versionItems(c, Source{}, Version{Repo: "artifacts-local", Path: "myapp/1"})
+	Expect(t, err).To(Not(BeNil()))
+}
@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -8,10 +9,11 @@ import (
 	"time"
 
 	"github.com/digitalocean/concourse-resource-library/artifactory"
-	"github.com/digitalocean/concourse-resource-library/git"
 	rlog "github.com/digitalocean/concourse-resource-library/log"
 	meta "github.com/digitalocean/concourse-resource-library/metadata"
 	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
 )
 
 // Put performs the Put operation for the resource
@@ -21,50 +23,150 @@ func Put(req PutRequest, dir string) (GetResponse, error) {
 		Metadata: meta.Metadata{},
 	}
 
-	c, err := newClient(req.Source)
+	log.Println("working directory:", dir)
+	log.Printf("put parameters: %+v", req.Params)
+
+	switch req.Params.Mode {
+	case "", ModeUpload:
+	case ModeCollect, ModePublish:
+		if req.Params.BuildInfoPath == "" {
+			err := fmt.Errorf("%s mode requires build_info_path", req.Params.Mode)
+			log.Println(err)
+			return get, err
+		}
+	case ModePromote:
+		return promote(req, dir)
+	case ModeCopy, ModeMove:
+		return transfer(req, dir)
+	case ModeProps:
+		return updateProperties(req, dir)
+	case ModeDelete:
+		return deleteItems(req, dir)
+	default:
+		err := fmt.Errorf("unsupported put mode: %s", req.Params.Mode)
+		log.Println(err)
+		return get, err
+	}
+
+	if req.Params.BuildRetention != nil {
+		err := req.Params.BuildRetention.Validate()
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+	}
+
+	b, repos, err := buildInfo(req.Params, dir)
 	if err != nil {
 		log.Println(err)
 		return get, err
 	}
 
-	log.Println("working directory:", dir)
-	log.Printf("put parameters: %+v", req.Params)
+	specs, err := uploadSpecs(req.Params)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	if req.Params.Mode == ModePublish && req.Params.Pattern == "" && len(req.Params.Specs) == 0 {
+		// publish the collected modules only
+		specs = nil
+	}
+
+	shared := moduleID(req.Params.Module, b.Name)
+	artifacts := []utils.FileInfo{}
+	uploaded, skipped := 0, 0
+
+	for _, spec := range specs {
+		props := properties(b, repos, req.Params.VcsTags)
+		for _, f := range []string{req.Params.Properties, spec.Properties} {
+			if f == "" {
+				continue
+			}
+
+			err := props.FromFile(filepath.Join(dir, f))
+			if err != nil {
+				rlog.StdErr("failed to read properties file", err)
+			}
+		}
+
+		rlog.StdErr("pattern", filepath.Join(dir, spec.Pattern))
+		rlog.StdErr("artifact properties", props)
 
-	b := buildInfo(req.Params, dir)
+		p := spec.uploadParams(dir, props)
+		for _, e := range req.Params.Exclude {
+			p.Exclusions = append(p.Exclusions, filepath.Join(dir, e))
+		}
 
-	props := properties(b)
-	if req.Params.Properties != "" {
-		err = props.FromFile(filepath.Join(dir, req.Params.Properties))
+		excluded, err := excludedFiles(p)
 		if err != nil {
-			rlog.StdErr("failed to read properties file", err)
+			rlog.StdErr("failed to list excluded files", err)
+		}
+		for _, f := range excluded {
+			rlog.StdErr("excluded", f)
+		}
+
+		a, n, k, err := upload(req.Source, req.Params, p)
+		if err != nil {
+			rlog.StdErr("failed to upload", err)
+			log.Println(err)
+			return get, err
+		}
+
+		for _, i := range a {
+			rlog.StdErr("artifact uploaded", i)
 		}
-	}
 
-	pattern := filepath.Join(dir, req.Params.Pattern)
-	rlog.StdErr("pattern", pattern)
-	rlog.StdErr("artifact properties", props)
+		b.Modules = addModule(b.Modules, moduleID(spec.Module, shared), a)
+		artifacts = append(artifacts, a...)
+		uploaded += n
+		skipped += k
+	}
 
-	artifacts, uploaded, err := c.UploadItems(pattern, req.Params.Target, props)
+	deps, err := dependencies(req.Source, req.Params, dir)
 	if err != nil {
-		rlog.StdErr("failed to upload", err)
 		log.Println(err)
 		return get, err
 	}
+	b.Modules = addDependencies(b.Modules, shared, deps)
+	rlog.StdErr("dependency count", len(deps))
+
+	var collected []aqlItem
+	if req.Params.Mode == ModePublish {
+		var modules []buildinfo.Module
+
+		modules, collected, err = readCollected(req.Source, req.Params.BuildInfoPath, b)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		b.Modules = mergeModules(modules, b.Modules...)
+		rlog.StdErr("collected module count", len(modules))
+	}
 
-	if req.Params.MinimumUpload > uploaded {
-		err = fmt.Errorf("failed to upload minimum (%v) count: uploaded %v artifacts", req.Params.MinimumUpload, uploaded)
+	if req.Params.MinimumUpload > uploaded+skipped {
+		err = fmt.Errorf("failed to upload minimum (%v) count: uploaded %v & skipped %v artifacts", req.Params.MinimumUpload, uploaded, skipped)
 		rlog.StdErr("failed to upload", err)
 		log.Println(err)
 		return get, err
 	}
 
 	rlog.StdErr("upload count", uploaded)
+	rlog.StdErr("skipped count", skipped)
+
+	if req.Params.DryRun {
+		if req.Params.BuildRetention != nil && req.Params.Mode != ModeCollect {
+			rlog.StdErr("dry run, would apply build retention", fmt.Sprintf("%+v", *req.Params.BuildRetention))
+		}
 
-	mod := buildinfo.Module{Id: moduleID(req.Params.Module, b.Name), Artifacts: []buildinfo.Artifact{}}
+		return dryRunReport(b, artifacts), nil
+	}
 
-	for _, a := range artifacts {
-		mod.Artifacts = append(mod.Artifacts, a.ToBuildArtifacts())
-		rlog.StdErr("artifact uploaded", a)
+	c, err := newClient(req.Source)
+	if err != nil {
+		log.Println(err)
+		return get, err
 	}
 
 	if len(artifacts) > 0 {
@@ -81,9 +183,19 @@ func Put(req PutRequest, dir string) (GetResponse, error) {
 		}
 	}
 
-	// TODO maybe metadata?
+	get.Metadata.Add("uploaded", fmt.Sprint(uploaded))
+	get.Metadata.Add("skipped", fmt.Sprint(skipped))
 
-	b.Modules = []buildinfo.Module{mod}
+	if req.Params.Mode == ModeCollect {
+		p, err := writeCollected(req.Source, req.Params.BuildInfoPath, shared, b)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+		rlog.StdErr("build info collected", p)
+
+		return get, nil
+	}
 
 	err = c.PublishBuildInfo(b)
 	if err != nil {
@@ -92,28 +204,90 @@ func Put(req PutRequest, dir string) (GetResponse, error) {
 	}
 	rlog.StdErr("build published", []string{b.Name, b.Number})
 
+	if len(collected) > 0 {
+		// the build is already published, the collected build info left behind is only reported
+		n, err := deleteCollected(req.Source, collected)
+		if err != nil {
+			rlog.StdErr("failed to delete collected build info", err)
+		}
+		rlog.StdErr("collected build info deleted", n)
+	}
+
+	if req.Params.BuildRetention != nil {
+		// the build is already published, failing to discard older builds is only reported
+		err = discardBuilds(req.Source, req.Params.BuildRetention, b.Name)
+		if err != nil {
+			rlog.StdErr("failed to apply build retention", err)
+		} else {
+			rlog.StdErr("build retention applied", b.Name)
+		}
+	}
+
 	return get, nil
 }
 
-func properties(b buildinfo.BuildInfo) artifactory.Properties {
+// uploadParams matches the local files of the pattern, uploading them to the target with the properties
+func uploadParams(pattern, target string, props artifactory.Properties) services.UploadParams {
+	p := services.NewUploadParams()
+	p.Pattern = pattern
+	p.Target = target
+	p.AddVcsProps = false
+	p.Recursive = true
+	p.Props = props.String()
+	p.Flat = true
+
+	return p
+}
+
+// dryRunReport reports the artifacts, properties & build info a put would publish
+func dryRunReport(b buildinfo.BuildInfo, artifacts []utils.FileInfo) GetResponse {
+	get := GetResponse{
+		Version:  Version{},
+		Metadata: meta.Metadata{},
+	}
+
+	for _, a := range artifacts {
+		rlog.StdErr("dry run, would upload", []string{a.LocalPath, a.ArtifactoryPath})
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		rlog.StdErr("failed to marshal build info", err)
+	}
+	rlog.StdErr("dry run, would publish build info", string(data))
+
+	get.Metadata.Add("dry-run", "true")
+	get.Metadata.Add("artifacts", fmt.Sprint(len(artifacts)))
+	get.Metadata.Add("build-name", b.Name)
+	get.Metadata.Add("build-number", b.Number)
+
+	return get
+}
+
+func properties(b buildinfo.BuildInfo, repos []vcsRepository, tags bool) artifactory.Properties {
 	props := artifactory.Properties{
 		artifactory.Property{Name: "build.name", Value: b.Name},
 		artifactory.Property{Name: "build.number", Value: b.Number},
 		artifactory.Property{Name: "build.started", Value: b.Started},
 	}
 
-	if b.Vcs != nil {
-		props = append(props, artifactory.Property{Name: "vcs.revision", Value: b.Vcs.Revision})
-		props = append(props, artifactory.Property{Name: "vcs.url", Value: b.Vcs.Url})
+	return append(props, vcsProperties(repos, tags)...)
+}
+
+func buildInfo(params PutParameters, dir string) (buildinfo.BuildInfo, []vcsRepository, error) {
+	name, err := buildName(params, dir)
+	if err != nil {
+		return buildinfo.BuildInfo{}, nil, err
 	}
 
-	return props
-}
+	number, err := buildNumber(params, dir)
+	if err != nil {
+		return buildinfo.BuildInfo{}, nil, err
+	}
 
-func buildInfo(params PutParameters, dir string) buildinfo.BuildInfo {
 	b := buildinfo.BuildInfo{
-		Name:       os.Getenv("BUILD_TEAM_NAME") + "-" + os.Getenv("BUILD_PIPELINE_NAME") + "-" + os.Getenv("BUILD_JOB_NAME"),
-		Number:     os.Getenv("BUILD_ID"),
+		Name:       name,
+		Number:     number,
 		Started:    time.Now().Format("2006-01-02T15:04:05.000-0700"),
 		Agent:      &buildinfo.Agent{Name: "Concourse"},
 		BuildAgent: &buildinfo.Agent{Name: "digitalocean/artifactory-resource"},
@@ -132,50 +306,10 @@ func buildInfo(params PutParameters, dir string) buildinfo.BuildInfo {
 		b.Properties = p.Env()
 	}
 
-	if params.RepositoryPath != "" {
-		b.Vcs = vcsInfo(filepath.Join(dir, params.RepositoryPath), params.Repository)
-	}
-
-	return b
-}
-
-func vcsInfo(path, repo string) *buildinfo.Vcs {
-	vcs := buildinfo.Vcs{}
-
-	log.Println("vcs path:", path)
-
-	g := git.Client{}
-	r, err := g.Open(path)
-	if err != nil {
-		rlog.StdErr("failed to open repository", err)
-		return &vcs
-	}
-
-	rev, err := r.Head()
-	if err != nil {
-		rlog.StdErr("failed to read vcs revision", err)
-		return &vcs
-	}
-	vcs.Revision = rev.Hash().String()
-
-	if repo != "" {
-		vcs.Url = repo
-		return &vcs
-	}
-
-	remotes, err := r.Remotes()
-	if err != nil {
-		rlog.StdErr("failed to read vcs info", err)
-		return &vcs
-	}
-
-	if len(remotes) > 0 && len(remotes[0].Config().URLs) > 0 {
-		url := remotes[0].Config().URLs[0]
-
-		vcs.Url = url
-	}
+	repos := vcsRepositories(params, dir)
+	vcsBuildInfo(&b, repos)
 
-	return &vcs
+	return b, repos, nil
 }
 
 func moduleID(m, b string) string {
@@ -1,9 +1,13 @@
 package resource
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
 	. "github.com/poy/onpar/expect"
 	. "github.com/poy/onpar/matchers"
 )
@@ -37,3 +41,130 @@ func TestBuildInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestPutDryRun(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "put")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"built/app-linux.tgz", "built/app-darwin.tgz", "built/notes.txt"} {
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	req := PutRequest{
+		// the endpoint is unreachable, a dry run must not contact it
+		Source: Source{Endpoint: "http://127.0.0.1:1/artifactory"},
+		Params: PutParameters{
+			Pattern:       "built/(*).tgz",
+			Target:        "artifacts-local/app/{1}.tgz",
+			MinimumUpload: 2,
+			DryRun:        true,
+		},
+	}
+
+	out, err := Put(req, dir)
+	Expect(t, err).To(BeNil())
+	Expect(t, out.Version).To(Equal(Version{}))
+
+	fields := map[string]string{}
+	for _, f := range out.Metadata {
+		fields[f.Name] = f.Value
+	}
+	Expect(t, fields["artifacts"]).To(Equal("2"))
+	Expect(t, fields["dry-run"]).To(Equal("true"))
+
+	req.Params.MinimumUpload = 3
+	_, err = Put(req, dir)
+	Expect(t, err).To(Not(BeNil()))
+}
+
+func TestPutDryRunSpecs(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "put")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"built/app-linux", "built/app-darwin", "docs/index.html", "docs/draft.tmp", "charts/app-1.0.0.tgz"} {
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	req := PutRequest{
+		Source: Source{Endpoint: "http://127.0.0.1:1/artifactory"},
+		Params: PutParameters{
+			Specs: []UploadSpec{
+				{Pattern: "built/*", Target: "artifacts-local/app/"},
+				{Pattern: "docs/*", Target: "docs-local/app/", Exclusions: []string{"docs/*.tmp"}},
+				{Pattern: "charts/*.tgz", Target: "helm-local/", Module: "charts"},
+			},
+			DryRun: true,
+		},
+	}
+
+	out, err := Put(req, dir)
+	Expect(t, err).To(BeNil())
+
+	fields := map[string]string{}
+	for _, f := range out.Metadata {
+		fields[f.Name] = f.Value
+	}
+	Expect(t, fields["artifacts"]).To(Equal("4"))
+}
+
+func TestPutDryRunExclude(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "put")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"built/app-linux", "built/app-linux.sha256", "built/app-darwin", "built/tmp/scratch"} {
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	tests := []struct {
+		description string
+		exclude     []string
+		expected    string
+	}{
+		{description: "no exclusions", expected: "4"},
+		{description: "exclude patterns", exclude: []string{"*.sha256", "built/tmp/*"}, expected: "2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			req := PutRequest{
+				Source: Source{Endpoint: "http://127.0.0.1:1/artifactory"},
+				Params: PutParameters{
+					Pattern: "built/*",
+					Target:  "artifacts-local/app/",
+					Exclude: tc.exclude,
+					DryRun:  true,
+				},
+			}
+
+			out, err := Put(req, dir)
+			Expect(t, err).To(BeNil())
+
+			fields := map[string]string{}
+			for _, f := range out.Metadata {
+				fields[f.Name] = f.Value
+			}
+			Expect(t, fields["artifacts"]).To(Equal(tc.expected))
+		})
+	}
+}
@@ -4,22 +4,40 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/digitalocean/artifactory-resource/internal"
 	m "github.com/digitalocean/concourse-resource-library/metadata"
 )
 
+// DefaultIncludeFields are always returned by the version query
+var DefaultIncludeFields = []string{"name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created"}
+
 // AQL provides the version query structure
 type AQL struct {
-	Raw  string `json:"raw,omitempty"`  // AQL to filter versions on
-	Repo string `json:"repo,omitempty"` // Artifactory repository to search
-	Path string `json:"path,omitempty"` // Artifactory repository sub-path to match
-	Name string `json:"name,omitempty"` // Artifactory artifact name to match
+	Raw     string   `json:"raw,omitempty"`     // AQL to filter versions on
+	Repo    string   `json:"repo,omitempty"`    // Artifactory repository to search
+	Path    string   `json:"path,omitempty"`    // Artifactory repository sub-path to match
+	Name    string   `json:"name,omitempty"`    // Artifactory artifact name to match
+	Sort    *AQLSort `json:"sort,omitempty"`    // Sort to apply to the query, defaults to `modified` ascending
+	Limit   int      `json:"limit,omitempty"`   // Limit the number of items returned by the query
+	Include []string `json:"include,omitempty"` // Include additional fields in the query results, e.g. `property`
+
+	Properties map[string]PropertyCriteria `json:"properties,omitempty"` // Properties artifacts must have, e.g. `release.status: approved` or `qa.passed: {not_equal: "false"}`
+}
+
+// AQLSort provides the sort clause of the version query
+type AQLSort struct {
+	Field     string `json:"field"`               // Field to sort on, e.g. `modified` or `name`
+	Direction string `json:"direction,omitempty"` // Direction to sort, `asc` (default) or `desc`
 }
 
-// UnmarshalJSON custom unmarshaller to convert PR number
+// UnmarshalJSON custom unmarshaller to ensure raw criteria is valid
 func (a *AQL) UnmarshalJSON(data []byte) error {
 	type Alias AQL
 	aux := struct {
@@ -33,26 +51,134 @@ func (a *AQL) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if aux.Raw == "" && aux.Repo != "" {
-		aux.Raw = fmt.Sprintf(`{"repo": "%s", "path": {"$match": "%s"}, "name": {"$match": "%s"}}`, aux.Repo, aux.Path, aux.Name)
+	_, err = ParseCriteria(aux.Raw)
+
+	return err
+}
+
+// Criteria returns the search criteria combining raw AQL with the repo, path & name fields
+func (a *AQL) Criteria() (Criteria, error) {
+	raw, err := ParseCriteria(a.Raw)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	fields := Criteria{}
+	if a.Repo != "" {
+		fields["repo"] = a.Repo
+	}
+	if a.Path != "" {
+		fields["path"] = Criteria{"$match": a.Path}
+	}
+	if a.Name != "" {
+		fields["name"] = Criteria{"$match": a.Name}
+	}
+
+	return and(raw, fields, propertiesCriteria(a.Properties)), nil
 }
 
-// SetModifiedTime appends the version modified time to the raw AQL query
-func (a *AQL) SetModifiedTime(v Version) {
-	if a.Raw == "" {
-		return
+// Validate ensures that the sort, limit & include clauses can be combined
+func (a *AQL) Validate() error {
+	switch {
+	case a.Limit < 0:
+		return errors.New("aql limit cannot be negative")
+	case a.Sort != nil && a.Sort.Field == "":
+		return errors.New("aql sort requires a field")
+	case a.Sort != nil && a.Sort.Direction != "" && a.Sort.Direction != "asc" && a.Sort.Direction != "desc":
+		return errors.New("aql sort direction must be asc or desc")
+	case a.includesProperties() && (a.Sort != nil || a.Limit > 0):
+		return errors.New("aql sort & limit cannot be used when including properties")
 	}
 
-	mod := internal.GetTimePointer(time.Now().AddDate(-2, 0, 0))
+	return validateProperties("aql", a.Properties)
+}
 
-	if v.Modified != nil && !v.Modified.IsZero() {
-		mod = v.Modified
+// Find returns the search criteria for items modified after since
+func (a *AQL) Find(since *time.Time) (Criteria, error) {
+	c, err := a.Criteria()
+	if err != nil {
+		return nil, err
 	}
 
-	a.Raw = fmt.Sprintf(`%s, "modified": {"$gt": "%s"}}`, a.Raw[:len(a.Raw)-1], mod.Format(time.RFC3339Nano))
+	if since != nil {
+		c = and(c, greaterThan("modified", since.Format(time.RFC3339Nano)))
+	}
+
+	return c, nil
+}
+
+// Query returns the complete `items.find` query for items modified after since, including the include, sort & limit clauses
+func (a *AQL) Query(since *time.Time) (string, error) {
+	c, err := a.Find(since)
+	if err != nil {
+		return "", err
+	}
+
+	q := fmt.Sprintf("items.find(%s)", c)
+
+	fields := append([]string{}, DefaultIncludeFields...)
+	for _, f := range a.Include {
+		if !contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	q += fmt.Sprintf(".include(%s)", quoteFields(fields))
+
+	if a.Sorted() {
+		field, direction := "modified", "asc"
+		if a.Sort != nil {
+			field = a.Sort.Field
+			if a.Sort.Direction != "" {
+				direction = a.Sort.Direction
+			}
+		}
+		q += fmt.Sprintf(`.sort({"$%s": [%s]})`, direction, quoteFields([]string{field}))
+	}
+
+	if a.Limit > 0 {
+		q += fmt.Sprintf(".limit(%d)", a.Limit)
+	}
+
+	return q, nil
+}
+
+// Sorted returns true if the query is sorted by Artifactory, which is not supported when including properties
+func (a *AQL) Sorted() bool {
+	return !a.includesProperties()
+}
+
+// Descending returns true if the query is sorted in descending order
+func (a *AQL) Descending() bool {
+	return a.Sort != nil && a.Sort.Direction == "desc"
+}
+
+func (a *AQL) includesProperties() bool {
+	for _, f := range a.Include {
+		if strings.HasPrefix(f, "property") || strings.HasPrefix(f, "@") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func quoteFields(fields []string) string {
+	q := make([]string, len(fields))
+	for i, f := range fields {
+		q[i] = strconv.Quote(f)
+	}
+
+	return strings.Join(q, ", ")
+}
+
+func contains(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Source represents the configuration for the resource
@@ -63,6 +189,16 @@ type Source struct {
 	AccessToken string `json:"access_token"`       // AccessToken for Artifactory API with permissions to Repository
 	APIKey      string `json:"api_key,omitempty"`  // APIKey for Artifactory API with permissions to Repository
 	AQL         AQL    `json:"aql"`                // AQL to filter versions on
+
+	InitialLookback string `json:"initial_lookback,omitempty"` // InitialLookback limits the first check to artifacts modified within a duration (e.g. `720h`), after an RFC3339 timestamp or `all`, defaults to 2 years
+
+	VersionRegex      string `json:"version_regex,omitempty"`      // VersionRegex extracts a semantic version from the artifact name (first capture group if defined) to order versions by
+	VersionConstraint string `json:"version_constraint,omitempty"` // VersionConstraint limits versions to a semantic version range, e.g. `>=1.2.0 <2.0.0`
+
+	Build *BuildQuery `json:"build,omitempty"` // Build to trigger on instead of items found by AQL
+
+	GroupBy       string `json:"group_by,omitempty"`       // GroupBy collapses items into one version per `path`, `build` (`build.name` & `build.number` properties) or `property`
+	GroupProperty string `json:"group_property,omitempty"` // GroupProperty is the property shared by the items of a version when GroupBy is `property`
 }
 
 // Validate ensures that the source configuration is valid
@@ -72,10 +208,34 @@ func (s *Source) Validate() error {
 		return errors.New("endpoint is required")
 	case s.User != "" && s.Password == "" && s.APIKey == "" && s.AccessToken == "":
 		return errors.New("user cannot be defined without a Password || AccessToken || APIKey")
-	case s.AQL.Raw == "" && s.AQL.Repo == "" && (s.AQL.Path == "" || s.AQL.Name == ""):
+	case s.Build != nil && s.Build.Name == "":
+		return errors.New("build requires a name")
+	case s.Build != nil && (s.GroupBy != "" || s.VersionRegex != "" || s.VersionConstraint != ""):
+		return errors.New("build cannot be combined with group_by, version_regex or version_constraint")
+	case s.Build == nil && s.AQL.Raw == "" && s.AQL.Repo == "" && len(s.AQL.Properties) == 0 && (s.AQL.Path == "" || s.AQL.Name == ""):
 		return errors.New("aql cannot be defined without a Password || AccessToken || APIKey")
 	}
 
+	err := s.AQL.Validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = initialLookback(s.InitialLookback, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = newSemverFilter(*s)
+	if err != nil {
+		return err
+	}
+
+	err = validateGroup(*s)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -85,6 +245,15 @@ type Version struct {
 	Path     string     `json:"path,omitempty"`
 	Name     string     `json:"name,omitempty"`
 	Modified *time.Time `json:"modified,omitempty"`
+	Sha256   string     `json:"sha256,omitempty"`
+	Sha1     string     `json:"sha1,omitempty"`
+	Size     string     `json:"size,omitempty"`
+
+	BuildName   string `json:"build_name,omitempty"`   // BuildName of the items when grouped by build
+	BuildNumber string `json:"build_number,omitempty"` // BuildNumber of the items when grouped by build
+	Group       string `json:"group,omitempty"`        // Group is the property value shared by the items when grouped by property
+
+	Started *time.Time `json:"started,omitempty"` // Started time of the build when triggering on builds
 }
 
 // Pattern returns the string needed to fetch the artifact
@@ -94,6 +263,10 @@ func (v *Version) Pattern() string {
 
 // Empty returns true if the version is empty
 func (v *Version) Empty() bool {
+	if v.Repo == "" && v.Path == "" && v.BuildName != "" && v.BuildNumber != "" {
+		return false
+	}
+
 	if v.Repo == "" || v.Path == "" {
 		return true
 	}
@@ -101,6 +274,33 @@ func (v *Version) Empty() bool {
 	return false
 }
 
+// VersionFile is the file name the version is written to within the `resource` metadata directory by get
+const VersionFile = "version.json"
+
+// ToFile writes the version as JSON to the directory
+func (v Version) ToFile(dir string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, VersionFile), data, 0644)
+}
+
+// ReadVersion reads the version written by a prior get step to the input directory
+func ReadVersion(input string) (Version, error) {
+	var v Version
+
+	data, err := ioutil.ReadFile(filepath.Join(input, "resource", VersionFile))
+	if err != nil {
+		return v, err
+	}
+
+	err = json.Unmarshal(data, &v)
+
+	return v, err
+}
+
 // CheckRequest is the data struct received from Concoruse by the resource check operation
 type CheckRequest struct {
 	Source  Source  `json:"source"`
@@ -127,7 +327,39 @@ func (r CheckResponse) Write() error {
 
 // GetParameters is the configuration for a resource step
 type GetParameters struct {
-	SkipDownload bool `json:"skip_download"` // SkipDownload is used with `put` steps to skip `get` step that Concourse runs by default
+	SkipDownload  bool                        `json:"skip_download"`            // SkipDownload is used with `put` steps to skip `get` step that Concourse runs by default
+	Include       []string                    `json:"include,omitempty"`        // Include glob patterns, only artifacts matching a pattern are downloaded
+	Exclude       []string                    `json:"exclude,omitempty"`        // Exclude glob patterns, artifacts matching a pattern are not downloaded
+	Properties    map[string]PropertyCriteria `json:"properties,omitempty"`     // Properties artifacts must have to be downloaded
+	Flat          bool                        `json:"flat,omitempty"`           // Flat places artifacts directly in the destination instead of following their Artifactory path
+	Directory     string                      `json:"directory,omitempty"`      // Directory within the destination to place artifacts in
+	Rename        string                      `json:"rename,omitempty"`         // Rename the primary artifact, the only artifact or the artifact named by the version, to a stable file name
+	Unpack        bool                        `json:"unpack,omitempty"`         // Unpack tar, tar.gz, tar.xz & zip artifacts into the destination
+	DeleteArchive bool                        `json:"delete_archive,omitempty"` // DeleteArchive removes archives after they are unpacked
+}
+
+// Validate ensures that the get parameters are valid
+func (p *GetParameters) Validate() error {
+	for _, g := range append(append([]string{}, p.Include...), p.Exclude...) {
+		_, err := path.Match(g, "")
+		if err != nil {
+			return fmt.Errorf("invalid glob %q: %s", g, err)
+		}
+	}
+
+	err := validateProperties("get", p.Properties)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case p.Directory != "" && (filepath.IsAbs(p.Directory) || strings.HasPrefix(filepath.Clean(p.Directory), "..")):
+		return fmt.Errorf("directory must be relative to the destination: %s", p.Directory)
+	case p.Rename != "" && (strings.ContainsAny(p.Rename, `/\`) || p.Rename == "." || p.Rename == ".."):
+		return fmt.Errorf("rename must be a file name: %s", p.Rename)
+	}
+
+	return nil
 }
 
 // GetRequest is the data struct received from Concoruse by the resource get operation
@@ -156,18 +388,52 @@ func (r GetResponse) Write() error {
 // PutParameters for the resource
 type PutParameters struct {
 	Pattern        string        `json:"pattern"`               // Pattern to find artifacts within inputs
+	Exclude        []string      `json:"exclude,omitempty"`     // Exclude patterns of files within inputs that are not uploaded, e.g. `*.sha256`
 	Target         string        `json:"target"`                // Target to upload artifacts too
 	Module         string        `json:"module,omitempty"`      // Module ID to associate the artifacts of the build to
 	BuildEnv       string        `json:"build_env,omitempty"`   // BuildEnv is path to file containing build environment values in `key=value\n` form, e.g. `env > env.txt`
 	EnvInclude     string        `json:"env_include,omitempty"` // EnvInclude case insensitive patterns in the form of "value1;value2;..." will be included
 	EnvExclude     string        `json:"env_exclude,omitempty"` // EnvExclude case insensitive patterns in the form of "value1;value2;..." will be excluded, defaults to `*password*;*psw*;*secret*;*key*;*token*`
-	Properties     string        `json:"properties,omitempty"`  // Properties is path to file containing artifact properties in `key=value\n` form
-	MinimumUpload  int           `json:"min_upload,omitempty"`  // MinimumUpload sets the minimum number of uploads expected & will error if not met
-	RepositoryPath string        `json:"repo_path,omitempty"`   // RepositoryPath sets the path to the input containing the repository (git support only)
-	Repository     string        `json:"repo,omitempty"`        // Repository set the repository url explicitly for compatibility with the git resource
+	Properties     string        `json:"properties,omitempty"`  // Properties is path to file containing artifact properties in `key=value\n` form, also used by the `properties` mode
+	MinimumUpload  int           `json:"min_upload,omitempty"`  // MinimumUpload sets the minimum number of uploads expected & will error if not met, skipped artifacts are counted
+	RepositoryPath Paths         `json:"repo_path,omitempty"`   // RepositoryPath sets the path, or list of paths, to the inputs containing the repositories (git support only)
+	Repository     string        `json:"repo,omitempty"`        // Repository set the repository url of the first repository explicitly for compatibility with the git resource
 	Get            GetParameters `json:"get,omitempty"`         // Get parameters for explicit get step after put
+
+	Mode               string                    `json:"mode,omitempty"`                // Mode of the put, `upload` (default), `collect`, `publish`, `promote`, `copy`, `move`, `properties` or `delete`
+	From               string                    `json:"from,omitempty"`                // From is the path to the input of a prior get step, its version is used instead of the current build
+	Promote            PromoteParameters         `json:"promote,omitempty"`             // Promote parameters for the `promote` mode
+	DryRun             bool                      `json:"dry_run,omitempty"`             // DryRun reports the changes of the put without making them
+	AQL                *AQL                      `json:"aql,omitempty"`                 // AQL finds the items of the `properties` & `delete` modes instead of From
+	DeleteProperties   []string                  `json:"delete_properties,omitempty"`   // DeleteProperties keys to remove in the `properties` mode
+	Recursive          bool                      `json:"recursive,omitempty"`           // Recursive applies the `properties` mode to every item within folders
+	Retention          RetentionParameters       `json:"retention,omitempty"`           // Retention policy applied to the AQL result of the `delete` mode
+	Threads            int                       `json:"threads,omitempty"`             // Threads uploading artifacts concurrently, defaults to 3
+	Retries            int                       `json:"retries,omitempty"`             // Retries of each artifact failing to upload
+	RetryBackoff       string                    `json:"retry_backoff,omitempty"`       // RetryBackoff duration before the first retry, doubled for each following retry, defaults to `1s`
+	Specs              []UploadSpec              `json:"specs,omitempty"`               // Specs of artifacts to upload instead of the pattern & target, published under a single build
+	SkipUnchanged      bool                      `json:"skip_unchanged,omitempty"`      // SkipUnchanged skips artifacts already stored at their target with the same checksums & checksum deploys the others
+	Dependencies       []string                  `json:"dependencies,omitempty"`        // Dependencies are paths to the inputs of prior get steps, their versions are recorded as build dependencies
+	DependencyManifest string                    `json:"dependency_manifest,omitempty"` // DependencyManifest is path to file of build dependencies in the `sha1sum` output format
+	BuildInfoPath      string                    `json:"build_info_path,omitempty"`     // BuildInfoPath is the Artifactory `repo/path` modules are collected to by the `collect` mode & published from by the `publish` mode
+	BuildName          string                    `json:"build_name,omitempty"`          // BuildName template of the build name, defaults to `{{.TeamName}}-{{.PipelineName}}-{{.JobName}}`
+	BuildNumber        string                    `json:"build_number,omitempty"`        // BuildNumber template of the build number, defaults to `{{.BuildID}}`
+	VcsTags            bool                      `json:"vcs_tags,omitempty"`            // VcsTags attaches the tags of the repositories HEAD as the `vcs.tag` artifact property
+	BuildRetention     *BuildRetentionParameters `json:"build_retention,omitempty"`     // BuildRetention discards older builds once the build is published
 }
 
+// Put modes
+const (
+	ModeUpload  = "upload"
+	ModePromote = "promote"
+	ModeCopy    = "copy"
+	ModeMove    = "move"
+	ModeProps   = "properties"
+	ModeDelete  = "delete"
+	ModeCollect = "collect"
+	ModePublish = "publish"
+)
+
 // PutRequest is the data struct received from Concoruse by the resource put operation
 type PutRequest struct {
 	Source Source        `json:"source"`
@@ -1,6 +1,9 @@
 package resource
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,31 +12,164 @@ import (
 	. "github.com/poy/onpar/matchers"
 )
 
-func TestAddModifiedTime(t *testing.T) {
+func TestAQLFind(t *testing.T) {
+	since := internal.GetTimePointer(time.Date(2020, time.May, 26, 0, 0, 0, 0, time.UTC))
+
 	tests := []struct {
 		description string
 		aql         AQL
-		version     Version
+		since       *time.Time
 		expected    string
+		expectError bool
 	}{
 		{
 			description: "empty aql",
 			aql:         AQL{},
-			version:     Version{},
-			expected:    "",
+			expected:    `{}`,
+		},
+		{
+			description: "empty aql with modified",
+			aql:         AQL{},
+			since:       since,
+			expected:    `{"modified":{"$gt":"2020-05-26T00:00:00Z"}}`,
 		},
 		{
-			description: "simple",
+			description: "raw",
 			aql:         AQL{Raw: `{"repo": "artifacts-local", "path": {"$match" : "changeset/*"}, "name": "artifact"}`},
-			version:     Version{Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 0, 0, 0, 0, time.UTC))},
-			expected:    `{"repo": "artifacts-local", "path": {"$match" : "changeset/*"}, "name": "artifact", "modified": {"$gt": "2020-05-26T00:00:00Z"}}`,
+			since:       since,
+			expected:    `{"$and":[{"name":"artifact","path":{"$match":"changeset/*"},"repo":"artifacts-local"},{"modified":{"$gt":"2020-05-26T00:00:00Z"}}]}`,
+		},
+		{
+			description: "raw with trailing whitespace",
+			aql:         AQL{Raw: "{\"repo\": \"artifacts-local\"}\n  "},
+			since:       since,
+			expected:    `{"$and":[{"repo":"artifacts-local"},{"modified":{"$gt":"2020-05-26T00:00:00Z"}}]}`,
+		},
+		{
+			description: "raw with $and",
+			aql:         AQL{Raw: `{"$and": [{"repo": "artifacts-local"}, {"size": {"$gt": 1024}}]}`},
+			since:       since,
+			expected:    `{"$and":[{"repo":"artifacts-local"},{"size":{"$gt":1024}},{"modified":{"$gt":"2020-05-26T00:00:00Z"}}]}`,
+		},
+		{
+			description: "raw with $or",
+			aql:         AQL{Raw: `{"$or": [{"name": "a"}, {"name": "b"}]}`},
+			expected:    `{"$or":[{"name":"a"},{"name":"b"}]}`,
+		},
+		{
+			description: "repo, path & name",
+			aql:         AQL{Repo: "artifacts-local", Path: "project/*", Name: "artifact"},
+			since:       since,
+			expected:    `{"$and":[{"name":{"$match":"artifact"},"path":{"$match":"project/*"},"repo":"artifacts-local"},{"modified":{"$gt":"2020-05-26T00:00:00Z"}}]}`,
+		},
+		{
+			description: "repo only",
+			aql:         AQL{Repo: "artifacts-local"},
+			expected:    `{"repo":"artifacts-local"}`,
+		},
+		{
+			description: "raw with repo",
+			aql:         AQL{Raw: `{"type": "file"}`, Repo: "artifacts-local"},
+			expected:    `{"$and":[{"type":"file"},{"repo":"artifacts-local"}]}`,
+		},
+		{
+			description: "escaped values",
+			aql:         AQL{Repo: "artifacts-local", Name: `my"artifact\<1>`},
+			expected:    `{"name":{"$match":"my\"artifact\\<1>"},"repo":"artifacts-local"}`,
+		},
+		{
+			description: "properties",
+			aql: AQL{
+				Repo: "artifacts-local",
+				Properties: map[string]PropertyCriteria{
+					"release.status": {Equal: "approved"},
+					"qa.passed":      {NotEqual: "false"},
+					"branch":         {Match: "release/*", NotMatch: "*-rc"},
+				},
+			},
+			expected: `{"$and":[{"repo":"artifacts-local"},{"@branch":{"$match":"release/*"}},{"@branch":{"$nmatch":"*-rc"}},{"@qa.passed":{"$ne":"false"}},{"@release.status":"approved"}]}`,
+		},
+		{
+			description: "invalid raw",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"`},
+			expectError: true,
+		},
+		{
+			description: "trailing data",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}, "modified": {}}`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := tc.aql.Find(tc.since)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestAQLQuery(t *testing.T) {
+	tests := []struct {
+		description string
+		aql         AQL
+		expected    string
+		expectError bool
+	}{
+		{
+			description: "default sort",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`},
+			expected:    `items.find({"repo":"artifacts-local"}).include("name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created").sort({"$asc": ["modified"]})`,
+		},
+		{
+			description: "sort & limit",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Sort: &AQLSort{Field: "created", Direction: "desc"}, Limit: 10},
+			expected:    `items.find({"repo":"artifacts-local"}).include("name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created").sort({"$desc": ["created"]}).limit(10)`,
+		},
+		{
+			description: "include properties",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Include: []string{"name", "property"}},
+			expected:    `items.find({"repo":"artifacts-local"}).include("name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created", "property")`,
+		},
+		{
+			description: "include properties with sort",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Include: []string{"property"}, Sort: &AQLSort{Field: "name"}},
+			expectError: true,
+		},
+		{
+			description: "invalid sort direction",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Sort: &AQLSort{Field: "name", Direction: "up"}},
+			expectError: true,
+		},
+		{
+			description: "empty property",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Properties: map[string]PropertyCriteria{"qa.passed": {}}},
+			expectError: true,
+		},
+		{
+			description: "negative limit",
+			aql:         AQL{Raw: `{"repo": "artifacts-local"}`, Limit: -1},
+			expectError: true,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
-			tc.aql.SetModifiedTime(tc.version)
-			out := tc.aql.Raw
+			err := tc.aql.Validate()
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			out, err := tc.aql.Query(nil)
+			Expect(t, err).To(BeNil())
 			Expect(t, out).To(Equal(tc.expected))
 		})
 	}
@@ -123,7 +259,6 @@ func TestCheckRequestUnmarshal(t *testing.T) {
 					User:     "me",
 					Password: "xxxx",
 					AQL: AQL{
-						Raw:  "{\"repo\": \"artifacts-local\", \"path\": {\"$match\": \"project/*\"}, \"name\": {\"$match\": \"artifact\"}}",
 						Repo: "artifacts-local",
 						Path: "project/*",
 						Name: "artifact",
@@ -132,6 +267,41 @@ func TestCheckRequestUnmarshal(t *testing.T) {
 			},
 			errorExpected: false,
 		},
+		{
+			description: "properties source w/empty version",
+			input: []byte(`
+			{
+				"source": {
+					"endpoint": "https://artifactory.example.com",
+					"user": "me",
+					"password": "xxxx",
+					"aql": {
+						"repo": "artifacts-local",
+						"properties": {
+							"release.status": "approved",
+							"qa.passed": {"not_equal": "false"}
+						}
+					}
+				},
+				"version": {}
+			}
+			`),
+			expected: CheckRequest{
+				Source: Source{
+					Endpoint: "https://artifactory.example.com",
+					User:     "me",
+					Password: "xxxx",
+					AQL: AQL{
+						Repo: "artifacts-local",
+						Properties: map[string]PropertyCriteria{
+							"release.status": {Equal: "approved"},
+							"qa.passed":      {NotEqual: "false"},
+						},
+					},
+				},
+			},
+			errorExpected: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -147,3 +317,30 @@ func TestCheckRequestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "resource"), os.ModePerm)
+	Expect(t, err).To(BeNil())
+
+	v := Version{
+		Repo:     "artifacts-local",
+		Path:     "project",
+		Name:     "artifact",
+		Modified: internal.GetTimePointer(time.Date(2020, time.May, 26, 0, 0, 0, 0, time.UTC)),
+		Sha1:     "abc123",
+	}
+
+	err = v.ToFile(filepath.Join(dir, "resource"))
+	Expect(t, err).To(BeNil())
+
+	out, err := ReadVersion(dir)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal(v))
+
+	_, err = ReadVersion(filepath.Join(dir, "missing"))
+	Expect(t, err).To(Not(BeNil()))
+}
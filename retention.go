@@ -0,0 +1,66 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+)
+
+// BuildRetentionParameters discard older builds of the published build, builds are discarded when matching any rule set
+type BuildRetentionParameters struct {
+	MaxBuilds       int      `json:"max_builds,omitempty"`       // MaxBuilds number of the most recent builds kept
+	MaxDays         int      `json:"max_days,omitempty"`         // MaxDays builds started more than MaxDays ago are discarded
+	DeleteArtifacts bool     `json:"delete_artifacts,omitempty"` // DeleteArtifacts deletes the artifacts of discarded builds
+	ExcludeBuilds   []string `json:"exclude_builds,omitempty"`   // ExcludeBuilds numbers that are never discarded
+}
+
+// Validate the build retention parameters
+func (r *BuildRetentionParameters) Validate() error {
+	switch {
+	case r.MaxBuilds < 0:
+		return fmt.Errorf("max_builds must not be negative: %d", r.MaxBuilds)
+	case r.MaxDays < 0:
+		return fmt.Errorf("max_days must not be negative: %d", r.MaxDays)
+	case r.MaxBuilds == 0 && r.MaxDays == 0:
+		return errors.New("build_retention requires max_builds or max_days")
+	}
+
+	for _, n := range r.ExcludeBuilds {
+		if n == "" || strings.Contains(n, ",") {
+			return fmt.Errorf("invalid exclude_builds number: %q", n)
+		}
+	}
+
+	return nil
+}
+
+// discardParams applies the retention to the builds of name
+func (r *BuildRetentionParameters) discardParams(name string) services.DiscardBuildsParams {
+	p := services.NewDiscardBuildsParams()
+	p.BuildName = name
+	p.DeleteArtifacts = r.DeleteArtifacts
+	p.ExcludeBuilds = strings.Join(r.ExcludeBuilds, ",")
+
+	if r.MaxBuilds > 0 {
+		p.MaxBuilds = strconv.Itoa(r.MaxBuilds)
+	}
+
+	if r.MaxDays > 0 {
+		p.MaxDays = strconv.Itoa(r.MaxDays)
+	}
+
+	return p
+}
+
+// discardBuilds applies the retention to the builds of name
+func discardBuilds(s Source, r *BuildRetentionParameters, name string) error {
+	sm, err := newServicesManager(s, false, 0)
+	if err != nil {
+		return err
+	}
+
+	return sm.DiscardBuilds(r.discardParams(name))
+}
@@ -0,0 +1,154 @@
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestBuildRetentionParametersValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		in          BuildRetentionParameters
+		expectError bool
+	}{
+		{
+			description: "max builds",
+			in:          BuildRetentionParameters{MaxBuilds: 50, ExcludeBuilds: []string{"1.0.0"}},
+		},
+		{
+			description: "max days",
+			in:          BuildRetentionParameters{MaxDays: 30, DeleteArtifacts: true},
+		},
+		{
+			description: "no rule",
+			in:          BuildRetentionParameters{DeleteArtifacts: true},
+			expectError: true,
+		},
+		{
+			description: "negative max builds",
+			in:          BuildRetentionParameters{MaxBuilds: -1, MaxDays: 30},
+			expectError: true,
+		},
+		{
+			description: "negative max days",
+			in:          BuildRetentionParameters{MaxDays: -1},
+			expectError: true,
+		},
+		{
+			description: "invalid excluded build",
+			in:          BuildRetentionParameters{MaxBuilds: 10, ExcludeBuilds: []string{"1,2"}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := tc.in.Validate()
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+		})
+	}
+}
+
+func TestDiscardParams(t *testing.T) {
+	r := BuildRetentionParameters{MaxBuilds: 50, DeleteArtifacts: true, ExcludeBuilds: []string{"12", "40"}}
+
+	Expect(t, r.discardParams("myapplication")).To(Equal(services.DiscardBuildsParams{
+		BuildName:       "myapplication",
+		MaxBuilds:       "50",
+		DeleteArtifacts: true,
+		ExcludeBuilds:   "12,40",
+	}))
+}
+
+func TestDiscardBuilds(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	var path string
+	body := map[string]interface{}{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	r := BuildRetentionParameters{MaxBuilds: 50, MaxDays: 30, ExcludeBuilds: []string{"12"}}
+
+	err := discardBuilds(Source{Endpoint: srv.URL, User: "ci", Password: "secret"}, &r, "myapplication")
+	Expect(t, err).To(BeNil())
+	Expect(t, path).To(Equal("/api/build/retention/myapplication"))
+	Expect(t, body["count"]).To(Equal("50"))
+	Expect(t, body["buildNumbersNotToBeDiscarded"]).To(Equal([]interface{}{"12"}))
+	Expect(t, body["deleteBuildArtifacts"]).To(Equal(false))
+	Expect(t, body["minimumBuildDate"]).To(Not(BeNil()))
+}
+
+func TestPutBuildRetentionFailure(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "retention")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "app.tgz"), []byte("app"), 0644)
+	Expect(t, err).To(BeNil())
+
+	var mu sync.Mutex
+	requests := map[string]int{}
+
+	// the build is published, then the retention request fails
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests[r.Method+" "+r.URL.Path]++
+
+		switch {
+		case r.URL.Path == "/api/search/aql":
+			w.Write([]byte(`{"results": [{"repo": "artifacts-local", "path": "app", "name": "app.tgz", "type": "file", "modified": "2020-05-26T20:00:00.000Z"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/api/build/retention/"):
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/api/build/":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	req := PutRequest{
+		Source: Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret"},
+		Params: PutParameters{
+			BuildName:      "myapplication",
+			BuildNumber:    "42",
+			Pattern:        "app.tgz",
+			Target:         "artifacts-local/app/",
+			BuildRetention: &BuildRetentionParameters{MaxBuilds: 50},
+		},
+	}
+
+	out, err := Put(req, dir)
+	Expect(t, err).To(BeNil())
+	Expect(t, out.Version.Name).To(Equal("app.tgz"))
+	Expect(t, requests["PUT /api/build/"]).To(Equal(1))
+	Expect(t, requests["POST /api/build/retention/myapplication"]).To(Equal(1))
+}
@@ -0,0 +1,93 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+)
+
+// UploadSpec for a set of artifacts published by a put, every spec is published under the same build
+type UploadSpec struct {
+	Pattern    string   `json:"pattern"`              // Pattern to find artifacts within inputs
+	Target     string   `json:"target"`               // Target to upload artifacts too
+	Properties string   `json:"properties,omitempty"` // Properties is path to file containing artifact properties in `key=value\n` form, added to the put properties
+	Exclusions []string `json:"exclusions,omitempty"` // Exclusions patterns of files within inputs that are not uploaded
+	Flat       *bool    `json:"flat,omitempty"`       // Flat uploads artifacts to the target without their local path, defaults to true
+	Recursive  *bool    `json:"recursive,omitempty"`  // Recursive matches the pattern within sub-directories, defaults to true
+	Module     string   `json:"module,omitempty"`     // Module ID of the artifacts, defaults to the module shared by specs without one
+}
+
+// uploadSpecs returns the upload specs of the put, the pattern & target form a single spec when no specs are set
+func uploadSpecs(params PutParameters) ([]UploadSpec, error) {
+	if len(params.Specs) == 0 {
+		return []UploadSpec{{Pattern: params.Pattern, Target: params.Target}}, nil
+	}
+
+	if params.Pattern != "" {
+		return nil, errors.New("pattern cannot be combined with specs")
+	}
+
+	for i, s := range params.Specs {
+		if s.Pattern == "" || s.Target == "" {
+			return nil, fmt.Errorf("spec %d requires a pattern & target", i)
+		}
+	}
+
+	return params.Specs, nil
+}
+
+// uploadParams returns the upload params of the spec with its local paths within dir
+func (s UploadSpec) uploadParams(dir string, props artifactory.Properties) services.UploadParams {
+	p := uploadParams(filepath.Join(dir, s.Pattern), s.Target, props)
+
+	if s.Flat != nil {
+		p.Flat = *s.Flat
+	}
+
+	if s.Recursive != nil {
+		p.Recursive = *s.Recursive
+	}
+
+	for _, e := range s.Exclusions {
+		p.Exclusions = append(p.Exclusions, filepath.Join(dir, e))
+	}
+
+	return p
+}
+
+// addModule appends the artifacts to the module with the ID, adding the module when missing
+func addModule(modules []buildinfo.Module, id string, artifacts []utils.FileInfo) []buildinfo.Module {
+	m := buildinfo.Module{Id: id, Artifacts: []buildinfo.Artifact{}}
+	for _, a := range artifacts {
+		m.Artifacts = append(m.Artifacts, a.ToBuildArtifacts())
+	}
+
+	return mergeModules(modules, m)
+}
+
+// mergeModules appends the artifacts & dependencies of each module to the module with the same ID, adding modules
+// when missing
+func mergeModules(modules []buildinfo.Module, more ...buildinfo.Module) []buildinfo.Module {
+	for _, m := range more {
+		i := 0
+		for ; i < len(modules); i++ {
+			if modules[i].Id == m.Id {
+				break
+			}
+		}
+
+		if i == len(modules) {
+			modules = append(modules, buildinfo.Module{Id: m.Id, Properties: m.Properties, Artifacts: []buildinfo.Artifact{}})
+		}
+
+		modules[i].Artifacts = append(modules[i].Artifacts, m.Artifacts...)
+		modules[i].Dependencies = append(modules[i].Dependencies, m.Dependencies...)
+	}
+
+	return modules
+}
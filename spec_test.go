@@ -0,0 +1,87 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestUploadSpecs(t *testing.T) {
+	tests := []struct {
+		description string
+		params      PutParameters
+		expected    []UploadSpec
+		expectError bool
+	}{
+		{
+			description: "pattern & target",
+			params:      PutParameters{Pattern: "built/*", Target: "artifacts-local/app/", Properties: "props.txt"},
+			expected:    []UploadSpec{{Pattern: "built/*", Target: "artifacts-local/app/"}},
+		},
+		{
+			description: "specs",
+			params: PutParameters{Specs: []UploadSpec{
+				{Pattern: "built/*", Target: "artifacts-local/app/"},
+				{Pattern: "charts/*.tgz", Target: "helm-local/app/", Module: "charts"},
+			}},
+			expected: []UploadSpec{
+				{Pattern: "built/*", Target: "artifacts-local/app/"},
+				{Pattern: "charts/*.tgz", Target: "helm-local/app/", Module: "charts"},
+			},
+		},
+		{
+			description: "specs with pattern",
+			params:      PutParameters{Pattern: "built/*", Specs: []UploadSpec{{Pattern: "docs/*", Target: "docs-local/"}}},
+			expectError: true,
+		},
+		{
+			description: "spec without target",
+			params:      PutParameters{Specs: []UploadSpec{{Pattern: "docs/*"}}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := uploadSpecs(tc.params)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestUploadSpecParams(t *testing.T) {
+	flat := false
+
+	p := UploadSpec{Pattern: "docs/(*)", Target: "docs-local/{1}", Exclusions: []string{"docs/*.tmp"}, Flat: &flat}.uploadParams("/tmp/build", nil)
+	Expect(t, p.Pattern).To(Equal("/tmp/build/docs/(*)"))
+	Expect(t, p.Target).To(Equal("docs-local/{1}"))
+	Expect(t, p.Exclusions).To(Equal([]string{"/tmp/build/docs/*.tmp"}))
+	Expect(t, p.Flat).To(BeFalse())
+	Expect(t, p.Recursive).To(BeTrue())
+}
+
+func TestAddModule(t *testing.T) {
+	artifact := func(name string) utils.FileInfo {
+		return utils.FileInfo{FileHashes: &utils.FileHashes{Sha1: name}, ArtifactoryPath: "artifacts-local/" + name, InternalArtifactoryPath: "artifacts-local/" + name}
+	}
+
+	var modules []buildinfo.Module
+	modules = addModule(modules, "app", []utils.FileInfo{artifact("a")})
+	modules = addModule(modules, "charts", []utils.FileInfo{artifact("b")})
+	modules = addModule(modules, "app", []utils.FileInfo{artifact("c")})
+
+	Expect(t, modules).To(HaveLen(2))
+	Expect(t, modules[0].Id).To(Equal("app"))
+	Expect(t, modules[0].Artifacts).To(HaveLen(2))
+	Expect(t, modules[1].Id).To(Equal("charts"))
+	Expect(t, modules[1].Artifacts).To(HaveLen(1))
+}
@@ -0,0 +1,136 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Default build templates, matching the build info published before templates were supported
+const (
+	DefaultBuildName   = "{{.TeamName}}-{{.PipelineName}}-{{.JobName}}"
+	DefaultBuildNumber = "{{.BuildID}}"
+)
+
+// BuildMetadata is the Concourse build metadata available to the build name & number templates
+type BuildMetadata struct {
+	BuildID      string            // BuildID is the internal `BUILD_ID` of the build, unique across Concourse
+	BuildName    string            // BuildName is the `BUILD_NAME` build number within its job, e.g. `42`
+	JobName      string            // JobName is the `BUILD_JOB_NAME`
+	PipelineName string            // PipelineName is the `BUILD_PIPELINE_NAME`
+	TeamName     string            // TeamName is the `BUILD_TEAM_NAME`
+	CreatedBy    string            // CreatedBy is the `BUILD_CREATED_BY` user of manually triggered builds
+	URL          string            // URL is the `ATC_EXTERNAL_URL`
+	InstanceVars map[string]string // InstanceVars of the pipeline from `BUILD_PIPELINE_INSTANCE_VARS`, nested values are JSON encoded
+}
+
+// buildMetadata reads the Concourse build metadata from the environment
+func buildMetadata() (BuildMetadata, error) {
+	m := BuildMetadata{
+		BuildID:      os.Getenv("BUILD_ID"),
+		BuildName:    os.Getenv("BUILD_NAME"),
+		JobName:      os.Getenv("BUILD_JOB_NAME"),
+		PipelineName: os.Getenv("BUILD_PIPELINE_NAME"),
+		TeamName:     os.Getenv("BUILD_TEAM_NAME"),
+		CreatedBy:    os.Getenv("BUILD_CREATED_BY"),
+		URL:          os.Getenv("ATC_EXTERNAL_URL"),
+		InstanceVars: map[string]string{},
+	}
+
+	vars := os.Getenv("BUILD_PIPELINE_INSTANCE_VARS")
+	if vars == "" {
+		return m, nil
+	}
+
+	raw := map[string]interface{}{}
+	err := json.Unmarshal([]byte(vars), &raw)
+	if err != nil {
+		return m, fmt.Errorf("failed to parse BUILD_PIPELINE_INSTANCE_VARS: %s", err)
+	}
+
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return m, err
+			}
+			s = string(data)
+		}
+
+		m.InstanceVars[k] = s
+	}
+
+	return m, nil
+}
+
+// buildName renders the build name template of the params, or the default
+func buildName(params PutParameters, dir string) (string, error) {
+	if params.BuildName == "" {
+		return renderBuild("build_name", DefaultBuildName, dir)
+	}
+
+	return renderTemplate("build_name", params.BuildName, dir)
+}
+
+// buildNumber renders the build number template of the params, or the default
+func buildNumber(params PutParameters, dir string) (string, error) {
+	if params.BuildNumber == "" {
+		return renderBuild("build_number", DefaultBuildNumber, dir)
+	}
+
+	return renderTemplate("build_number", params.BuildNumber, dir)
+}
+
+// renderTemplate renders a template of the params, rendering an empty value is an error
+func renderTemplate(name, text, dir string) (string, error) {
+	s, err := renderBuild(name, text, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if s == "" {
+		return "", fmt.Errorf("%s template %q rendered an empty value", name, text)
+	}
+
+	return s, nil
+}
+
+// renderBuild renders a template over the build metadata, `env` returns an environment variable & `file` the trimmed
+// content of a file within the inputs
+func renderBuild(name, text, dir string) (string, error) {
+	m, err := buildMetadata()
+	if err != nil {
+		return "", err
+	}
+
+	funcs := template.FuncMap{
+		"env": os.Getenv,
+		"file": func(p string) (string, error) {
+			data, err := ioutil.ReadFile(filepath.Join(dir, p))
+			if err != nil {
+				return "", err
+			}
+
+			return strings.TrimSpace(string(data)), nil
+		},
+	}
+
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %s", name, err)
+	}
+
+	var out bytes.Buffer
+	err = t.Execute(&out, m)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %s", name, err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
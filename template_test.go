@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestBuildTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "version"), []byte("1.4.2\n"), 0644)
+	Expect(t, err).To(BeNil())
+
+	env := map[string]string{
+		"BUILD_ID":                     "1234",
+		"BUILD_NAME":                   "42",
+		"BUILD_JOB_NAME":               "build",
+		"BUILD_PIPELINE_NAME":          "myapplication",
+		"BUILD_TEAM_NAME":              "main",
+		"BUILD_PIPELINE_INSTANCE_VARS": `{"branch":"release/1.4","region":{"name":"nyc"}}`,
+		"RELEASE_CHANNEL":              "stable",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	tests := []struct {
+		description    string
+		in             PutParameters
+		expectedName   string
+		expectedNumber string
+		expectError    bool
+	}{
+		{
+			description:    "defaults",
+			in:             PutParameters{},
+			expectedName:   "main-myapplication-build",
+			expectedNumber: "1234",
+		},
+		{
+			description:    "job build name & version file",
+			in:             PutParameters{BuildName: "{{.PipelineName}}/{{.JobName}}", BuildNumber: `{{file "version"}}-{{.BuildName}}`},
+			expectedName:   "myapplication/build",
+			expectedNumber: "1.4.2-42",
+		},
+		{
+			description:    "instance vars & environment",
+			in:             PutParameters{BuildName: `{{.PipelineName}}-{{index .InstanceVars "branch"}}-{{.InstanceVars.region}}`, BuildNumber: `{{env "RELEASE_CHANNEL"}}.{{.BuildName}}`},
+			expectedName:   `myapplication-release/1.4-{"name":"nyc"}`,
+			expectedNumber: "stable.42",
+		},
+		{
+			description: "missing file",
+			in:          PutParameters{BuildNumber: `{{file "missing"}}`},
+			expectError: true,
+		},
+		{
+			description: "missing instance var",
+			in:          PutParameters{BuildName: `{{.InstanceVars.missing}}`},
+			expectError: true,
+		},
+		{
+			description: "empty value",
+			in:          PutParameters{BuildNumber: `{{env "UNSET_VARIABLE"}}`},
+			expectError: true,
+		},
+		{
+			description: "invalid template",
+			in:          PutParameters{BuildName: "{{.JobName"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			name, nameErr := buildName(tc.in, dir)
+			number, numberErr := buildNumber(tc.in, dir)
+
+			if tc.expectError {
+				Expect(t, nameErr == nil && numberErr == nil).To(BeFalse())
+				return
+			}
+
+			Expect(t, nameErr).To(BeNil())
+			Expect(t, numberErr).To(BeNil())
+			Expect(t, name).To(Equal(tc.expectedName))
+			Expect(t, number).To(Equal(tc.expectedNumber))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	meta "github.com/digitalocean/concourse-resource-library/metadata"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+)
+
+// transfer copies or moves the version found in From to the target within Artifactory, without re-uploading
+func transfer(req PutRequest, dir string) (GetResponse, error) {
+	get := GetResponse{
+		Version:  Version{},
+		Metadata: meta.Metadata{},
+	}
+
+	if req.Params.From == "" {
+		err := fmt.Errorf("%s requires from", req.Params.Mode)
+		log.Println(err)
+		return get, err
+	}
+
+	v, err := ReadVersion(filepath.Join(dir, req.Params.From))
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	if v.Repo == "" || v.Path == "" || v.Name == "" {
+		err = fmt.Errorf("%s requires an item version: %+v", req.Params.Mode, v)
+		log.Println(err)
+		return get, err
+	}
+
+	dest, err := transferTarget(v, req.Params.Target)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	p := services.NewMoveCopyParams()
+	p.Pattern = v.Pattern()
+	p.Target = dest.Pattern()
+	p.Flat = true
+
+	sm, err := newServicesManager(req.Source, req.Params.DryRun, 0)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	op := sm.Copy
+	if req.Params.Mode == ModeMove {
+		op = sm.Move
+	}
+
+	success, failed, err := op(p)
+	if err != nil {
+		log.Println(err)
+		return get, err
+	}
+
+	if failed > 0 || success == 0 {
+		err = fmt.Errorf("failed to %s %s to %s: %d succeeded, %d failed", req.Params.Mode, p.Pattern, p.Target, success, failed)
+		log.Println(err)
+		return get, err
+	}
+	rlog.StdErr(req.Params.Mode+" complete", []string{p.Pattern, p.Target})
+
+	// a dry run returns an empty version like upload dry runs, the target was never written for a get to download
+	if !req.Params.DryRun {
+		c, err := newClient(req.Source)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		i, err := c.SearchItem(dest.Pattern())
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+
+		get.Version, err = processItem(i)
+		if err != nil {
+			log.Println(err)
+			return get, err
+		}
+		get.Version.Sha256 = v.Sha256
+	}
+
+	get.Metadata.Add("source", v.Pattern())
+	get.Metadata.Add("target", dest.Pattern())
+	if req.Params.DryRun {
+		get.Metadata.Add("dry-run", "true")
+	}
+
+	return get, nil
+}
+
+// transferTarget returns the version at the target, a target ending in `/` keeps the version name
+func transferTarget(v Version, target string) (Version, error) {
+	parts := strings.SplitN(strings.TrimPrefix(target, "/"), "/", 2)
+	if len(parts) < 2 || parts[0] == "" {
+		return v, errors.New("target must be in the form repo/path/ or repo/path/name")
+	}
+
+	dest := Version{
+		Repo:   parts[0],
+		Name:   path.Base(parts[1]),
+		Path:   path.Dir(parts[1]),
+		Sha256: v.Sha256,
+		Sha1:   v.Sha1,
+		Size:   v.Size,
+	}
+
+	if parts[1] == "" || strings.HasSuffix(parts[1], "/") {
+		dest.Name = v.Name
+		dest.Path = path.Clean(parts[1])
+	}
+
+	if dest.Pattern() == v.Pattern() {
+		return v, errors.New("target must differ from the version")
+	}
+
+	return dest, nil
+}
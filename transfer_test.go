@@ -0,0 +1,114 @@
+package resource
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestTransferTarget(t *testing.T) {
+	v := Version{Repo: "snapshots-local", Path: "project/1.0.0", Name: "artifact.tgz", Sha1: "abc123", Size: "1024"}
+
+	tests := []struct {
+		description string
+		target      string
+		expected    Version
+		expectError bool
+	}{
+		{
+			description: "directory target",
+			target:      "releases-local/project/1.0.0/",
+			expected:    Version{Repo: "releases-local", Path: "project/1.0.0", Name: "artifact.tgz", Sha1: "abc123", Size: "1024"},
+		},
+		{
+			description: "file target",
+			target:      "releases-local/project/artifact-1.0.0.tgz",
+			expected:    Version{Repo: "releases-local", Path: "project", Name: "artifact-1.0.0.tgz", Sha1: "abc123", Size: "1024"},
+		},
+		{
+			description: "repository root",
+			target:      "releases-local/",
+			expected:    Version{Repo: "releases-local", Path: ".", Name: "artifact.tgz", Sha1: "abc123", Size: "1024"},
+		},
+		{
+			description: "repository only",
+			target:      "releases-local",
+			expectError: true,
+		},
+		{
+			description: "empty target",
+			expectError: true,
+		},
+		{
+			description: "same location",
+			target:      "snapshots-local/project/1.0.0/",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			out, err := transferTarget(v, tc.target)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestTransferDryRun(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "transfer")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "myapplication", "resource"), os.ModePerm)
+	Expect(t, err).To(BeNil())
+
+	v := Version{Repo: "snapshots-local", Path: "app", Name: "app.tgz"}
+	err = v.ToFile(filepath.Join(dir, "myapplication", "resource"))
+	Expect(t, err).To(BeNil())
+
+	var mu sync.Mutex
+	copies := []string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/api/search/aql":
+			w.Write([]byte(`{"results": [{"repo": "snapshots-local", "path": "app", "name": "app.tgz", "type": "file"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/api/copy/"):
+			copies = append(copies, r.URL.Path+"?"+r.URL.RawQuery)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	req := PutRequest{
+		Source: Source{Endpoint: srv.URL, User: "ci", Password: "secret"},
+		Params: PutParameters{Mode: ModeCopy, From: "myapplication", Target: "releases-local/app/", DryRun: true},
+	}
+
+	out, err := Put(req, dir)
+	Expect(t, err).To(BeNil())
+	Expect(t, out.Version).To(Equal(Version{}))
+	Expect(t, copies).To(HaveLen(1))
+	Expect(t, strings.Contains(copies[0], "dry=1")).To(BeTrue())
+}
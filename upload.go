@@ -0,0 +1,435 @@
+package resource
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	jfrog "github.com/jfrog/jfrog-client-go/artifactory"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/fspatterns"
+	"github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	clientutils "github.com/jfrog/jfrog-client-go/utils"
+)
+
+// DefaultRetryBackoff is the wait before the first retry of a failed upload, doubled for every following retry
+const DefaultRetryBackoff = time.Second
+
+// uploadResult is the outcome of uploading a single file
+type uploadResult struct {
+	LocalPath string
+	Target    string
+	Attempts  int
+	Skipped   bool
+	Err       error
+}
+
+func (r uploadResult) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("failed %s -> %s after %d attempts: %s", r.LocalPath, r.Target, r.Attempts, r.Err)
+	case r.Skipped:
+		return fmt.Sprintf("skipped unchanged %s -> %s", r.LocalPath, r.Target)
+	}
+
+	return fmt.Sprintf("uploaded %s -> %s in %d attempts", r.LocalPath, r.Target, r.Attempts)
+}
+
+// upload uploads the files matching the params, files failing to upload are retried individually with backoff
+// & the outcome of every file is reported. The artifacts returned include unchanged files that were skipped
+func upload(s Source, params PutParameters, p services.UploadParams) ([]utils.FileInfo, int, int, error) {
+	if params.DryRun {
+		sm, err := newServicesManager(s, true, params.Threads)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		artifacts, uploaded, _, err := sm.UploadFiles(cloneUploadParams(p))
+		return artifacts, uploaded, 0, err
+	}
+
+	backoff, err := retryBackoff(params.RetryBackoff)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if params.Retries == 0 && !params.SkipUnchanged {
+		sm, err := newServicesManager(s, false, params.Threads)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		return uploadOnce(sm, p)
+	}
+
+	// a dry run matches the files & computes their targets & checksums locally, files missing from the upload are then retried
+	planner, err := newServicesManager(s, true, params.Threads)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	plan, _, _, err := planner.UploadFiles(cloneUploadParams(p))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	sm, err := newServicesManager(s, false, params.Threads)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	pending, skipped := plan, []utils.FileInfo{}
+	if params.SkipUnchanged {
+		// checksum deploy every file, Artifactory then links binaries it already stores instead of receiving them
+		p.MinChecksumDeploy = 0
+
+		pending, skipped, err = skipUnchanged(s, sm, plan, p.Props)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	var artifacts []utils.FileInfo
+	var failed int
+
+	switch {
+	case len(pending) == 0:
+	case len(skipped) == 0:
+		artifacts, _, failed, err = sm.UploadFiles(cloneUploadParams(p))
+	default:
+		files := []services.UploadParams{}
+		for _, f := range pending {
+			files = append(files, singleUploadParams(p, f))
+		}
+
+		artifacts, _, failed, err = sm.UploadFiles(files...)
+	}
+	if err != nil {
+		log.Println("upload errors, retrying failed files:", err)
+	}
+	log.Println("upload failures:", failed)
+
+	uploaded := map[string]bool{}
+	for _, a := range artifacts {
+		uploaded[a.LocalPath] = true
+	}
+
+	results := []uploadResult{}
+	for _, f := range skipped {
+		results = append(results, uploadResult{LocalPath: f.LocalPath, Target: f.InternalArtifactoryPath, Skipped: true})
+	}
+
+	for _, f := range pending {
+		if uploaded[f.LocalPath] {
+			results = append(results, uploadResult{LocalPath: f.LocalPath, Target: f.InternalArtifactoryPath, Attempts: 1})
+			continue
+		}
+
+		a, r := retryUpload(sm, p, f, params.Retries, backoff)
+		if r.Err == nil {
+			artifacts = append(artifacts, a)
+		}
+
+		results = append(results, r)
+	}
+
+	var failures []string
+	for _, r := range results {
+		rlog.StdErr("upload", r.String())
+
+		if r.Err != nil {
+			failures = append(failures, r.LocalPath)
+		}
+	}
+
+	n := len(artifacts)
+	artifacts = append(artifacts, skipped...)
+
+	if len(failures) > 0 {
+		return artifacts, n, len(skipped), fmt.Errorf("failed to upload %d files: %s", len(failures), strings.Join(failures, ", "))
+	}
+
+	return artifacts, n, len(skipped), nil
+}
+
+// uploadOnce uploads the files matching the params without planning them, the local files are only listed to report
+// the files that failed
+func uploadOnce(sm uploader, p services.UploadParams) ([]utils.FileInfo, int, int, error) {
+	artifacts, _, failed, err := sm.UploadFiles(cloneUploadParams(p))
+	if err != nil {
+		log.Println("upload errors:", err)
+	}
+	log.Println("upload failures:", failed)
+
+	uploaded := map[string]bool{}
+	for _, a := range artifacts {
+		uploaded[a.LocalPath] = true
+		rlog.StdErr("upload", uploadResult{LocalPath: a.LocalPath, Target: a.InternalArtifactoryPath, Attempts: 1}.String())
+	}
+
+	if failed == 0 && err == nil {
+		return artifacts, len(artifacts), 0, nil
+	}
+
+	files, lerr := matchedFiles(p)
+	if lerr != nil {
+		rlog.StdErr("failed to list files", lerr)
+	}
+
+	var failures []string
+	for _, f := range files {
+		if uploaded[f] {
+			continue
+		}
+
+		rlog.StdErr("upload", uploadResult{LocalPath: f, Attempts: 1, Err: fmt.Errorf("upload failed")}.String())
+		failures = append(failures, f)
+	}
+
+	switch {
+	case len(failures) > 0:
+		return artifacts, len(artifacts), 0, fmt.Errorf("failed to upload %d files: %s", len(failures), strings.Join(failures, ", "))
+	case failed > 0:
+		return artifacts, len(artifacts), 0, fmt.Errorf("failed to upload %d files", failed)
+	}
+
+	return artifacts, len(artifacts), 0, err
+}
+
+// skipUnchanged splits the planned files into those to upload & those already stored at their target with the same
+// checksums, the properties of unchanged files are updated in place
+func skipUnchanged(s Source, sm *jfrog.ArtifactoryServicesManager, plan []utils.FileInfo, props string) ([]utils.FileInfo, []utils.FileInfo, error) {
+	if len(plan) == 0 {
+		return plan, nil, nil
+	}
+
+	c, err := newClient(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, err := searchItems(c, fmt.Sprintf("items.find(%s).include(%s)", targetCriteria(plan), quoteFields(DefaultIncludeFields)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, skipped := unchangedFiles(plan, items)
+
+	if len(skipped) > 0 && props != "" {
+		targets := []utils.ResultItem{}
+		for _, f := range skipped {
+			repo, path, name := itemLocation(f.InternalArtifactoryPath)
+			targets = append(targets, utils.ResultItem{Repo: repo, Path: path, Name: name, Type: "file"})
+		}
+
+		_, err = sm.SetProps(services.PropsParams{Items: targets, Props: props})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return pending, skipped, nil
+}
+
+// unchangedFiles splits the planned files into those to upload & those matching the checksums of the stored items
+func unchangedFiles(plan []utils.FileInfo, items []aqlItem) ([]utils.FileInfo, []utils.FileInfo) {
+	stored := map[string]aqlItem{}
+	for _, i := range items {
+		stored[i.Repo+"/"+path.Join(i.Path, i.Name)] = i
+	}
+
+	pending, skipped := []utils.FileInfo{}, []utils.FileInfo{}
+	for _, f := range plan {
+		i, ok := stored[f.InternalArtifactoryPath]
+		if ok && f.FileHashes != nil && f.Sha1 != "" && i.Actual_Sha1 == f.Sha1 && (i.Sha256 == "" || i.Sha256 == f.Sha256) {
+			skipped = append(skipped, f)
+			continue
+		}
+
+		pending = append(pending, f)
+	}
+
+	return pending, skipped
+}
+
+// targetCriteria matches the items stored at the targets of the planned files
+func targetCriteria(plan []utils.FileInfo) Criteria {
+	targets := []Criteria{}
+	for _, f := range plan {
+		repo, path, name := itemLocation(f.InternalArtifactoryPath)
+		targets = append(targets, Criteria{"repo": repo, "path": path, "name": name})
+	}
+
+	return Criteria{"$or": targets}
+}
+
+// itemLocation splits an Artifactory `repo/path/name` into its parts, items at the repository root have the path `.`
+func itemLocation(p string) (string, string, string) {
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) < 2 {
+		return parts[0], ".", ""
+	}
+
+	return parts[0], path.Dir(parts[1]), path.Base(parts[1])
+}
+
+// retryUpload uploads a single planned file to its target up to retries times, doubling the backoff between attempts
+func retryUpload(sm uploader, p services.UploadParams, f utils.FileInfo, retries int, backoff time.Duration) (utils.FileInfo, uploadResult) {
+	r := uploadResult{LocalPath: f.LocalPath, Target: f.InternalArtifactoryPath, Attempts: 1, Err: fmt.Errorf("upload failed")}
+
+	single := singleUploadParams(p, f)
+
+	for i := 0; i < retries; i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		r.Attempts++
+
+		artifacts, uploaded, _, err := sm.UploadFiles(cloneUploadParams(single))
+		switch {
+		case err != nil:
+			r.Err = err
+		case uploaded == 0 || len(artifacts) == 0:
+			r.Err = fmt.Errorf("upload failed")
+		default:
+			r.Err = nil
+			return artifacts[0], r
+		}
+
+		log.Printf("retry %d of %s failed: %s", i+1, f.LocalPath, r.Err)
+	}
+
+	return utils.FileInfo{}, r
+}
+
+// singleUploadParams uploads a single planned file to its target with the properties of the params
+func singleUploadParams(p services.UploadParams, f utils.FileInfo) services.UploadParams {
+	single := services.NewUploadParams()
+	single.Pattern = f.LocalPath
+	single.Target = f.InternalArtifactoryPath
+	single.Props = p.Props
+	single.Flat = true
+	single.MinChecksumDeploy = p.MinChecksumDeploy
+
+	return single
+}
+
+// uploader uploads files, satisfied by the JFrog services manager
+type uploader interface {
+	UploadFiles(params ...services.UploadParams) ([]utils.FileInfo, int, int, error)
+}
+
+// matchedFiles returns the local files matching the pattern of the params & none of its exclusions, without reading them
+func matchedFiles(p services.UploadParams) ([]string, error) {
+	root, err := fspatterns.GetRootPath(p.Pattern, p.Target, p.Regexp, p.Symlink)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	pattern, err := regexp.Compile(clientutils.PrepareLocalPathForUpload(p.Pattern, p.Regexp))
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := fspatterns.GetPaths(root, p.Recursive, p.IncludeDirs, p.Symlink)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := fspatterns.PrepareExcludePathPattern(p)
+
+	// the paths are filtered as the JFrog client filters the paths it uploads
+	var matched []string
+	for _, path := range paths {
+		matches, _, _, err := fspatterns.PrepareAndFilterPaths(path, exclude, p.Symlink, p.IncludeDirs, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) > 0 {
+			matched = append(matched, path)
+		}
+	}
+	sort.Strings(matched)
+
+	return matched, nil
+}
+
+// excludedFiles returns the local files matching the pattern of the params that are skipped by its exclusions
+func excludedFiles(p services.UploadParams) ([]string, error) {
+	exclude := fspatterns.PrepareExcludePathPattern(p)
+	if exclude == "" {
+		return nil, nil
+	}
+
+	root, err := fspatterns.GetRootPath(p.Pattern, p.Target, p.Regexp, p.Symlink)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		// single files are uploaded without applying exclusions
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(clientutils.PrepareLocalPathForUpload(p.Pattern, p.Regexp))
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := fspatterns.GetPaths(root, p.Recursive, false, p.Symlink)
+	if err != nil {
+		return nil, err
+	}
+
+	var excluded []string
+	for _, path := range paths {
+		ok, err := fspatterns.IsPathExcluded(path, exclude)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && pattern.MatchString(path) {
+			excluded = append(excluded, path)
+		}
+	}
+	sort.Strings(excluded)
+
+	return excluded, nil
+}
+
+// cloneUploadParams copies the params, the JFrog client rewrites the pattern of the params it uploads
+func cloneUploadParams(p services.UploadParams) services.UploadParams {
+	common := *p.ArtifactoryCommonParams
+	p.ArtifactoryCommonParams = &common
+
+	return p
+}
+
+func retryBackoff(b string) (time.Duration, error) {
+	if b == "" {
+		return DefaultRetryBackoff, nil
+	}
+
+	d, err := time.ParseDuration(b)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("retry_backoff must be a duration: %s", b)
+	}
+
+	return d, nil
+}
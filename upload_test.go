@@ -0,0 +1,264 @@
+package resource
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	jlog "github.com/jfrog/jfrog-client-go/utils/log"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestUpload(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "upload")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"app-linux.tgz", "app-darwin.tgz"} {
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	tests := []struct {
+		description string
+		failures    int
+		retries     int
+		uploaded    int
+		expectError bool
+	}{
+		{
+			description: "no failures",
+			uploaded:    2,
+		},
+		{
+			description: "transient failure retried",
+			failures:    2,
+			retries:     2,
+			uploaded:    2,
+		},
+		{
+			description: "retries exhausted",
+			failures:    3,
+			retries:     2,
+			uploaded:    1,
+			expectError: true,
+		},
+		{
+			description: "failure without retries",
+			failures:    1,
+			uploaded:    1,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var mu sync.Mutex
+			failures := tc.failures
+
+			// the linux artifact fails the first uploads with a bad gateway
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if strings.Contains(r.URL.Path, "app-linux.tgz") && failures > 0 {
+					failures--
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+
+			params := PutParameters{Threads: 2, Retries: tc.retries, RetryBackoff: "1ms"}
+			p := uploadParams(filepath.Join(dir, "*.tgz"), "artifacts-local/app/", nil)
+
+			start := time.Now()
+			artifacts, uploaded, skipped, err := upload(Source{Endpoint: srv.URL}, params, p)
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				Expect(t, strings.Contains(err.Error(), "app-linux.tgz")).To(BeTrue())
+			} else {
+				Expect(t, err).To(BeNil())
+			}
+
+			Expect(t, uploaded).To(Equal(tc.uploaded))
+			Expect(t, skipped).To(Equal(0))
+			Expect(t, artifacts).To(HaveLen(tc.uploaded))
+			Expect(t, time.Since(start) < time.Second).To(BeTrue())
+		})
+	}
+}
+
+func TestUploadSkipUnchanged(t *testing.T) {
+	jlog.SetLogger(jlog.NewLogger(jlog.ERROR, ioutil.Discard))
+
+	dir, err := ioutil.TempDir("", "upload")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"app-linux.tgz", "app-darwin.tgz"} {
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	// the darwin artifact is already stored with the same checksum, the linux artifact has changed
+	stored := fmt.Sprintf(`{"results": [
+		{"repo": "artifacts-local", "path": "app", "name": "app-darwin.tgz", "actual_sha1": "%x"},
+		{"repo": "artifacts-local", "path": "app", "name": "app-linux.tgz", "actual_sha1": "0000"}
+	]}`, sha1.Sum([]byte("app-darwin.tgz")))
+
+	var mu sync.Mutex
+	requests := map[string]int{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests[r.Method+" "+r.URL.Path]++
+
+		switch {
+		case r.URL.Path == "/api/search/aql":
+			w.Write([]byte(stored))
+		case strings.HasPrefix(r.URL.Path, "/api/storage/"):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	params := PutParameters{SkipUnchanged: true}
+	p := uploadParams(filepath.Join(dir, "*.tgz"), "artifacts-local/app/", artifactory.Properties{{Name: "build.number", Value: "2"}})
+
+	artifacts, uploaded, skipped, err := upload(Source{Endpoint: srv.URL + "/", User: "ci", Password: "secret"}, params, p)
+	Expect(t, err).To(BeNil())
+	Expect(t, uploaded).To(Equal(1))
+	Expect(t, skipped).To(Equal(1))
+	Expect(t, artifacts).To(HaveLen(2))
+
+	Expect(t, requests["PUT /artifacts-local/app/app-linux.tgz;build.number=2"]).To(Equal(1))
+	Expect(t, requests["PUT /artifacts-local/app/app-darwin.tgz;build.number=2"]).To(Equal(0))
+	Expect(t, requests["PUT /api/storage/artifacts-local/app/app-darwin.tgz"]).To(Equal(1))
+}
+
+func TestItemLocation(t *testing.T) {
+	repo, path, name := itemLocation("artifacts-local/app/1.0.0/app.tgz")
+	Expect(t, []string{repo, path, name}).To(Equal([]string{"artifacts-local", "app/1.0.0", "app.tgz"}))
+
+	repo, path, name = itemLocation("artifacts-local/app.tgz")
+	Expect(t, []string{repo, path, name}).To(Equal([]string{"artifacts-local", ".", "app.tgz"}))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	d, err := retryBackoff("")
+	Expect(t, err).To(BeNil())
+	Expect(t, d).To(Equal(DefaultRetryBackoff))
+
+	d, err = retryBackoff("250ms")
+	Expect(t, err).To(BeNil())
+	Expect(t, d).To(Equal(250 * time.Millisecond))
+
+	_, err = retryBackoff("soon")
+	Expect(t, err).To(Not(BeNil()))
+}
+
+func TestMatchedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "match")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"built/app", "built/app.sha256", "built/tmp/cache.tmp", "docs/index.html"} {
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	p := uploadParams(filepath.Join(dir, "built/*"), "artifacts-local/app/", nil)
+	p.Exclusions = []string{filepath.Join(dir, "*.sha256")}
+
+	out, err := matchedFiles(p)
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal([]string{filepath.Join(dir, "built/app"), filepath.Join(dir, "built/tmp/cache.tmp")}))
+
+	out, err = matchedFiles(uploadParams(filepath.Join(dir, "docs/index.html"), "artifacts-local/app/", nil))
+	Expect(t, err).To(BeNil())
+	Expect(t, out).To(Equal([]string{filepath.Join(dir, "docs/index.html")}))
+}
+
+func TestExcludedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exclude")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"built/app", "built/app.sha256", "built/build.log", "built/tmp/cache.tmp", "docs/index.html"} {
+		err = os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), os.ModePerm)
+		Expect(t, err).To(BeNil())
+
+		err = ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644)
+		Expect(t, err).To(BeNil())
+	}
+
+	tests := []struct {
+		description string
+		pattern     string
+		exclusions  []string
+		expected    []string
+	}{
+		{
+			description: "no exclusions",
+			pattern:     "built/*",
+		},
+		{
+			description: "extensions",
+			pattern:     "built/*",
+			exclusions:  []string{"*.sha256", "*.log"},
+			expected:    []string{"built/app.sha256", "built/build.log"},
+		},
+		{
+			description: "directory",
+			pattern:     "built/*",
+			exclusions:  []string{"built/tmp/*"},
+			expected:    []string{"built/tmp/cache.tmp"},
+		},
+		{
+			description: "outside of the pattern",
+			pattern:     "built/*",
+			exclusions:  []string{"*.html"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			p := uploadParams(filepath.Join(dir, tc.pattern), "artifacts-local/app/", nil)
+			for _, e := range tc.exclusions {
+				p.Exclusions = append(p.Exclusions, filepath.Join(dir, e))
+			}
+
+			out, err := excludedFiles(p)
+			Expect(t, err).To(BeNil())
+
+			var expected []string
+			for _, e := range tc.expected {
+				expected = append(expected, filepath.Join(dir, e))
+			}
+
+			Expect(t, out).To(Equal(expected))
+		})
+	}
+}
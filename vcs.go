@@ -0,0 +1,269 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/concourse-resource-library/artifactory"
+	"github.com/digitalocean/concourse-resource-library/git"
+	rlog "github.com/digitalocean/concourse-resource-library/log"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+)
+
+// Paths of inputs, unmarshalled from a single path or a list of paths
+type Paths []string
+
+// UnmarshalJSON accepts a single path or a list of paths
+func (p *Paths) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*p = Paths{}
+		if path != "" {
+			*p = Paths{path}
+		}
+
+		return nil
+	}
+
+	var paths []string
+	err := json.Unmarshal(data, &paths)
+	if err != nil {
+		return fmt.Errorf("expected a path or a list of paths: %s", err)
+	}
+
+	*p = paths
+
+	return nil
+}
+
+// vcsRepository is the git metadata of a repository the build is made from
+type vcsRepository struct {
+	Path     string   // Path of the input containing the repository
+	Url      string   // Url of the first remote, or the explicit repository url
+	Revision string   // Revision of HEAD
+	Branch   string   // Branch checked out, or containing the detached HEAD
+	Message  string   // Message of the HEAD commit
+	Author   string   // Author of the HEAD commit in `name <email>` form
+	Tags     []string // Tags pointing to HEAD
+}
+
+// vcsRepositories reads the git metadata of every repository path of the params, the explicit repository url
+// applies to the first repository
+func vcsRepositories(params PutParameters, dir string) []vcsRepository {
+	repos := []vcsRepository{}
+	for i, p := range params.RepositoryPath {
+		url := ""
+		if i == 0 {
+			url = params.Repository
+		}
+
+		repos = append(repos, vcsInfo(dir, p, url))
+	}
+
+	return repos
+}
+
+// vcsInfo reads the git metadata of the repository at path within dir, failures are reported & leave the metadata
+// read so far
+func vcsInfo(dir, path, url string) vcsRepository {
+	vcs := vcsRepository{Path: path}
+
+	log.Println("vcs path:", path)
+
+	g := git.Client{}
+	r, err := g.Open(filepath.Join(dir, path))
+	if err != nil {
+		rlog.StdErr("failed to open repository", err)
+		return vcs
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		rlog.StdErr("failed to read vcs revision", err)
+		return vcs
+	}
+	vcs.Revision = head.Hash().String()
+
+	vcs.Branch, err = headBranch(r, head)
+	if err != nil {
+		rlog.StdErr("failed to read vcs branch", err)
+	}
+
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		rlog.StdErr("failed to read vcs commit", err)
+	} else {
+		vcs.Message = strings.TrimSpace(commit.Message)
+		vcs.Author = fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email)
+	}
+
+	vcs.Tags, err = headTags(r, head)
+	if err != nil {
+		rlog.StdErr("failed to read vcs tags", err)
+	}
+
+	if url != "" {
+		vcs.Url = url
+		return vcs
+	}
+
+	remotes, err := r.Remotes()
+	if err != nil {
+		rlog.StdErr("failed to read vcs info", err)
+		return vcs
+	}
+
+	if len(remotes) > 0 && len(remotes[0].Config().URLs) > 0 {
+		vcs.Url = remotes[0].Config().URLs[0]
+	}
+
+	return vcs
+}
+
+// headBranch returns the branch checked out, the git resource checks out a detached HEAD so the local & then remote
+// branches pointing to HEAD are searched
+func headBranch(r *gogit.Repository, head *plumbing.Reference) (string, error) {
+	if head.Name().IsBranch() {
+		return head.Name().Short(), nil
+	}
+
+	refs, err := r.References()
+	if err != nil {
+		return "", err
+	}
+
+	var local, remote []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || ref.Hash() != head.Hash() {
+			return nil
+		}
+
+		switch {
+		case ref.Name().IsBranch():
+			local = append(local, ref.Name().Short())
+		case ref.Name().IsRemote():
+			// drop the remote name, e.g. `origin/main`
+			parts := strings.SplitN(ref.Name().Short(), "/", 2)
+			if len(parts) == 2 && parts[1] != "HEAD" {
+				remote = append(remote, parts[1])
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(local)
+	sort.Strings(remote)
+
+	switch {
+	case len(local) > 0:
+		return local[0], nil
+	case len(remote) > 0:
+		return remote[0], nil
+	}
+
+	return "", nil
+}
+
+// headTags returns the lightweight & annotated tags pointing to HEAD
+func headTags(r *gogit.Repository, head *plumbing.Reference) ([]string, error) {
+	refs, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		target := ref.Hash()
+
+		tag, err := r.TagObject(ref.Hash())
+		if err == nil {
+			target = tag.Target
+		}
+
+		if target == head.Hash() {
+			tags = append(tags, ref.Name().Short())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tags)
+
+	return tags, nil
+}
+
+// vcsBuildInfo records the first repository as the build info vcs & the metadata of every repository as build
+// properties keyed by its path, the build info of jfrog-client-go v0.11.0 only holds a single vcs url & revision so
+// these properties are not vcs entries
+func vcsBuildInfo(b *buildinfo.BuildInfo, repos []vcsRepository) {
+	if len(repos) == 0 {
+		return
+	}
+
+	b.Vcs = &buildinfo.Vcs{Url: repos[0].Url, Revision: repos[0].Revision}
+
+	if b.Properties == nil {
+		b.Properties = buildinfo.Env{}
+	}
+
+	for _, r := range repos {
+		prefix := "vcs." + r.Path + "."
+
+		for k, v := range map[string]string{
+			"url":      r.Url,
+			"revision": r.Revision,
+			"branch":   r.Branch,
+			"message":  r.Message,
+			"author":   r.Author,
+			"tags":     strings.Join(r.Tags, ","),
+		} {
+			if v != "" {
+				b.Properties[prefix+k] = v
+			}
+		}
+	}
+}
+
+// vcsProperties are the artifact properties of the first repository, the tags of every repository are attached
+// as the multi value `vcs.tag` property when tags is set, empty values are skipped
+func vcsProperties(repos []vcsRepository, tags bool) artifactory.Properties {
+	props := artifactory.Properties{}
+	if len(repos) == 0 {
+		return props
+	}
+
+	for _, p := range []artifactory.Property{
+		{Name: "vcs.revision", Value: repos[0].Revision},
+		{Name: "vcs.url", Value: repos[0].Url},
+		{Name: "vcs.branch", Value: repos[0].Branch},
+	} {
+		if p.Value != "" {
+			props = append(props, p)
+		}
+	}
+
+	if tags {
+		all := []string{}
+		for _, r := range repos {
+			all = append(all, r.Tags...)
+		}
+
+		if len(all) > 0 {
+			props = append(props, artifactory.Property{Name: "vcs.tag", Value: strings.Join(all, ",")})
+		}
+	}
+
+	return props
+}
@@ -0,0 +1,147 @@
+package resource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestPathsUnmarshal(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		expected    Paths
+		expectError bool
+	}{
+		{description: "single path", in: `"code"`, expected: Paths{"code"}},
+		{description: "empty path", in: `""`, expected: Paths{}},
+		{description: "list of paths", in: `["code", "charts"]`, expected: Paths{"code", "charts"}},
+		{description: "invalid", in: `{"path": "code"}`, expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var out Paths
+			err := json.Unmarshal([]byte(tc.in), &out)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestVcsInfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vcs")
+	Expect(t, err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	sign := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Now()}
+
+	r, err := gogit.PlainInit(filepath.Join(dir, "code"), false)
+	Expect(t, err).To(BeNil())
+
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/example/code.git"}})
+	Expect(t, err).To(BeNil())
+
+	w, err := r.Worktree()
+	Expect(t, err).To(BeNil())
+
+	err = ioutil.WriteFile(filepath.Join(dir, "code", "main.go"), []byte("package main"), 0644)
+	Expect(t, err).To(BeNil())
+
+	_, err = w.Add("main.go")
+	Expect(t, err).To(BeNil())
+
+	first, err := w.Commit("initial commit\n", &gogit.CommitOptions{Author: sign})
+	Expect(t, err).To(BeNil())
+
+	_, err = r.CreateTag("v0.1.0", first, nil)
+	Expect(t, err).To(BeNil())
+
+	head, err := w.Commit("release 1.0.0\n\nwith notes\n", &gogit.CommitOptions{Author: sign})
+	Expect(t, err).To(BeNil())
+
+	_, err = r.CreateTag("v1.0.0", head, &gogit.CreateTagOptions{Tagger: sign, Message: "1.0.0"})
+	Expect(t, err).To(BeNil())
+
+	_, err = r.CreateTag("latest", head, nil)
+	Expect(t, err).To(BeNil())
+
+	vcs := vcsInfo(dir, "code", "")
+	Expect(t, vcs).To(Equal(vcsRepository{
+		Path:     "code",
+		Url:      "https://github.com/example/code.git",
+		Revision: head.String(),
+		Branch:   "master",
+		Message:  "release 1.0.0\n\nwith notes",
+		Author:   "Jane Doe <jane@example.com>",
+		Tags:     []string{"latest", "v1.0.0"},
+	}))
+
+	// the git resource checks out a detached HEAD of the branch
+	err = r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "release"), head))
+	Expect(t, err).To(BeNil())
+
+	err = w.Checkout(&gogit.CheckoutOptions{Hash: head})
+	Expect(t, err).To(BeNil())
+
+	err = r.Storer.RemoveReference(plumbing.NewBranchReferenceName("master"))
+	Expect(t, err).To(BeNil())
+
+	vcs = vcsInfo(dir, "code", "https://example.com/code.git")
+	Expect(t, vcs.Branch).To(Equal("release"))
+	Expect(t, vcs.Url).To(Equal("https://example.com/code.git"))
+
+	vcs = vcsInfo(dir, "missing", "")
+	Expect(t, vcs).To(Equal(vcsRepository{Path: "missing"}))
+}
+
+func TestVcsMetadata(t *testing.T) {
+	repos := []vcsRepository{
+		{Path: "code", Url: "https://github.com/example/code.git", Revision: "abc", Branch: "main", Message: "fix", Author: "Jane Doe <jane@example.com>", Tags: []string{"v1.0.0", "latest"}},
+		{Path: "charts", Url: "https://github.com/example/charts.git", Revision: "def", Tags: []string{"charts-2.0.0"}},
+	}
+
+	b := buildinfo.BuildInfo{}
+	vcsBuildInfo(&b, repos)
+	Expect(t, b.Vcs).To(Equal(&buildinfo.Vcs{Url: "https://github.com/example/code.git", Revision: "abc"}))
+	Expect(t, b.Properties).To(Equal(buildinfo.Env{
+		"vcs.code.url":        "https://github.com/example/code.git",
+		"vcs.code.revision":   "abc",
+		"vcs.code.branch":     "main",
+		"vcs.code.message":    "fix",
+		"vcs.code.author":     "Jane Doe <jane@example.com>",
+		"vcs.code.tags":       "v1.0.0,latest",
+		"vcs.charts.url":      "https://github.com/example/charts.git",
+		"vcs.charts.revision": "def",
+		"vcs.charts.tags":     "charts-2.0.0",
+	}))
+
+	Expect(t, vcsProperties(repos, false).String()).To(Equal("vcs.revision=abc;vcs.url=https://github.com/example/code.git;vcs.branch=main;"))
+	Expect(t, vcsProperties(repos, true).String()).To(Equal("vcs.revision=abc;vcs.url=https://github.com/example/code.git;vcs.branch=main;vcs.tag=v1.0.0,latest,charts-2.0.0;"))
+	Expect(t, vcsProperties(nil, true)).To(HaveLen(0))
+
+	// a detached HEAD outside of any branch & without tags
+	detached := []vcsRepository{{Path: "code", Url: "https://github.com/example/code.git", Revision: "abc"}}
+	Expect(t, vcsProperties(detached, true).String()).To(Equal("vcs.revision=abc;vcs.url=https://github.com/example/code.git;"))
+
+	b = buildinfo.BuildInfo{}
+	vcsBuildInfo(&b, nil)
+	Expect(t, b.Vcs).To(BeNil())
+}
@@ -0,0 +1,111 @@
+package resource
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// DefaultVersionRegex is used to extract a semantic version from an artifact name when Source.VersionRegex is not set
+const DefaultVersionRegex = `(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`
+
+// semverFilter extracts, filters & orders versions by the semantic version found in the artifact name
+type semverFilter struct {
+	regex      *regexp.Regexp
+	constraint *semver.Constraints
+}
+
+// newSemverFilter builds a filter from the source configuration, returning nil when semver ordering is not enabled
+func newSemverFilter(s Source) (*semverFilter, error) {
+	if s.VersionRegex == "" && s.VersionConstraint == "" {
+		return nil, nil
+	}
+
+	expr := s.VersionRegex
+	if expr == "" {
+		expr = DefaultVersionRegex
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version_regex: %s", err)
+	}
+
+	f := &semverFilter{regex: re}
+
+	if s.VersionConstraint != "" {
+		f.constraint, err = semver.NewConstraint(s.VersionConstraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version_constraint: %s", err)
+		}
+	}
+
+	return f, nil
+}
+
+// parse returns the semantic version found in the artifact name, using the first capture group when defined
+func (f *semverFilter) parse(v Version) (*semver.Version, error) {
+	m := f.regex.FindStringSubmatch(v.Name)
+	if m == nil {
+		return nil, fmt.Errorf("no version found in name: %s", v.Name)
+	}
+
+	raw := m[0]
+	if len(m) > 1 {
+		raw = m[1]
+	}
+
+	return semver.NewVersion(raw)
+}
+
+// apply drops versions without a valid semantic version, outside of the constraint or lower than the input
+// version, then sorts the remainder in ascending semantic version order
+func (f *semverFilter) apply(in Version, res CheckResponse) CheckResponse {
+	type entry struct {
+		version Version
+		semver  *semver.Version
+	}
+
+	var floor *semver.Version
+	if !in.Empty() {
+		s, err := f.parse(in)
+		if err == nil {
+			floor = s
+		}
+	}
+
+	entries := []entry{}
+	for _, v := range res {
+		s, err := f.parse(v)
+		if err != nil {
+			log.Println("skipping version:", err)
+			continue
+		}
+
+		if f.constraint != nil && !f.constraint.Check(s) {
+			log.Println("skipping version outside of constraint:", v.Name)
+			continue
+		}
+
+		if floor != nil && s.LessThan(floor) {
+			log.Println("skipping version lower than input version:", v.Name)
+			continue
+		}
+
+		entries = append(entries, entry{version: v, semver: s})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].semver.LessThan(entries[j].semver)
+	})
+
+	out := CheckResponse{}
+	for _, e := range entries {
+		out = append(out, e.version)
+	}
+
+	return out
+}
@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"testing"
+
+	. "github.com/poy/onpar/expect"
+	. "github.com/poy/onpar/matchers"
+)
+
+func TestSemverFilter(t *testing.T) {
+	tests := []struct {
+		description string
+		source      Source
+		input       Version
+		found       CheckResponse
+		expected    CheckResponse
+		expectNil   bool
+		expectError bool
+	}{
+		{
+			description: "not enabled",
+			source:      Source{},
+			expectNil:   true,
+		},
+		{
+			description: "invalid regex",
+			source:      Source{VersionRegex: "myapp-(["},
+			expectError: true,
+		},
+		{
+			description: "invalid constraint",
+			source:      Source{VersionConstraint: ">= one"},
+			expectError: true,
+		},
+		{
+			description: "sorted by semver",
+			source:      Source{VersionRegex: `myapp-(.*)\.tgz`},
+			input:       Version{},
+			found: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.9.3.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.2.0.tgz"},
+			},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.2.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.9.3.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+			},
+		},
+		{
+			description: "default regex skips unversioned names",
+			source:      Source{VersionConstraint: "*"},
+			input:       Version{},
+			found: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-2.0.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-latest.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.0.0.tgz"},
+			},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.0.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-2.0.0.tgz"},
+			},
+		},
+		{
+			description: "constrained range",
+			source:      Source{VersionRegex: `myapp-(.*)\.tgz`, VersionConstraint: ">=1.2.0 <2.0.0"},
+			input:       Version{},
+			found: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-2.0.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.1.9.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.2.0.tgz"},
+			},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.2.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+			},
+		},
+		{
+			description: "lower than input version",
+			source:      Source{VersionRegex: `myapp-(.*)\.tgz`},
+			input:       Version{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+			found: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.9.4.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.1.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+			},
+			expected: CheckResponse{
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.0.tgz"},
+				{Repo: "artifact-local", Path: "myapp", Name: "myapp-1.10.1.tgz"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			f, err := newSemverFilter(tc.source)
+
+			if tc.expectError {
+				Expect(t, err).To(Not(BeNil()))
+				return
+			}
+
+			Expect(t, err).To(BeNil())
+
+			if tc.expectNil {
+				Expect(t, f == nil).To(BeTrue())
+				return
+			}
+
+			out := f.apply(tc.input, tc.found)
+			Expect(t, out).To(Equal(tc.expected))
+		})
+	}
+}